@@ -0,0 +1,143 @@
+package fbmessenger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+)
+
+// GraphError is returned by the Sender when the Graph API responds with an error.
+type GraphError struct {
+	Message      string `json:"message"`
+	Type         string `json:"type"`
+	Code         int    `json:"code"`
+	ErrorSubcode int    `json:"error_subcode"`
+	IsTransient  bool   `json:"is_transient"`
+	FBTraceID    string `json:"fbtrace_id"`
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int `json:"-"`
+}
+
+func (err *GraphError) Error() string {
+	return err.Message
+}
+
+// Graph API error codes.
+const (
+	codeAPIUnknown           = 1
+	codeAPIService           = 2
+	codeAPITooManyCalls      = 4
+	codePermissionDenied     = 10
+	codeUserTooManyCalls     = 17
+	codeInvalidParameter     = 100
+	codeSessionInvalid       = 102
+	codeAccessTokenInvalid   = 190
+	codePermissionsMin       = 200
+	codePermissionsMax       = 299
+	codePageTooManyCalls     = 32
+	codeMissingPermission    = 230
+	codeUserUnavailable      = 551
+	codeCustomRateLimit      = 613
+	codeBusinessRateLimitMin = 80000
+	codeBusinessRateLimitMax = 80014
+	codeTemporarySendFailure = 1200
+)
+
+// Graph API error subcodes.
+const (
+	subcodeNoMatchingUser        = 2018001
+	subcodeOutsideWindow         = 2018278
+	subcodeOutsideWindowNoPolicy = 2018065
+)
+
+func asGraphError(err error) (*GraphError, bool) {
+	var gerr *GraphError
+	if errors.As(err, &gerr) {
+		return gerr, true
+	}
+	return nil, false
+}
+
+// IsRateLimited returns if err indicates that an application, user,
+// page or business use case rate limit has been reached.
+func IsRateLimited(err error) bool {
+	gerr, ok := asGraphError(err)
+	if !ok {
+		return false
+	}
+	switch gerr.Code {
+	case codeAPITooManyCalls, codeUserTooManyCalls, codePageTooManyCalls, codeCustomRateLimit:
+		return true
+	}
+	return gerr.Code >= codeBusinessRateLimitMin && gerr.Code <= codeBusinessRateLimitMax
+}
+
+// IsOutsideMessagingWindow returns if err indicates that a message was sent
+// outside of the allowed messaging window.
+func IsOutsideMessagingWindow(err error) bool {
+	gerr, ok := asGraphError(err)
+	if !ok {
+		return false
+	}
+	return gerr.Code == codePermissionDenied &&
+		(gerr.ErrorSubcode == subcodeOutsideWindow || gerr.ErrorSubcode == subcodeOutsideWindowNoPolicy)
+}
+
+// IsUserUnavailable returns if err indicates that the recipient
+// doesn't exist or can't receive messages at the moment.
+func IsUserUnavailable(err error) bool {
+	gerr, ok := asGraphError(err)
+	if !ok {
+		return false
+	}
+	return gerr.Code == codeUserUnavailable ||
+		(gerr.Code == codeInvalidParameter && gerr.ErrorSubcode == subcodeNoMatchingUser)
+}
+
+// IsTokenInvalid returns if err indicates that the access token
+// is invalid or has expired.
+func IsTokenInvalid(err error) bool {
+	gerr, ok := asGraphError(err)
+	if !ok {
+		return false
+	}
+	return gerr.Code == codeAccessTokenInvalid || gerr.Code == codeSessionInvalid
+}
+
+// IsPermissionDenied returns if err indicates that the access token
+// lacks a permission required for the call.
+func IsPermissionDenied(err error) bool {
+	gerr, ok := asGraphError(err)
+	if !ok {
+		return false
+	}
+	if IsOutsideMessagingWindow(err) {
+		return false
+	}
+	return gerr.Code == codePermissionDenied || gerr.Code == codeMissingPermission ||
+		(gerr.Code >= codePermissionsMin && gerr.Code <= codePermissionsMax)
+}
+
+// IsTemporary returns if err indicates a transient failure, like a
+// server error, a throttled call or a reset connection, after which
+// the call may succeed when it's retried.
+// Canceled contexts and exceeded deadlines are never temporary.
+func IsTemporary(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if gerr, ok := asGraphError(err); ok {
+		switch gerr.Code {
+		case codeAPIUnknown, codeAPIService, codeTemporarySendFailure:
+			return true
+		}
+		return gerr.IsTransient || gerr.StatusCode >= 500 || IsRateLimited(err)
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+	var nerr net.Error
+	return errors.As(err, &nerr) && nerr.Timeout()
+}
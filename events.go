@@ -1,12 +1,18 @@
 package fbmessenger
 
+import "encoding/json"
+
 // Event is an empty interface that is type switched when handeled.
 type Event interface{}
 
 // CallbackUnsupported occurs when an unknown callback was received.
 type CallbackUnsupported struct {
 	Metadata
-	Extra map[string]interface{}
+	// Raw is the callback as received.
+	Raw json.RawMessage
+	// Extra contains the fields of the callback by key,
+	// except of the sender, recipient and timestamp.
+	Extra map[string]json.RawMessage
 }
 
 // VerificationFailed occurs when a webhook verification failed.
@@ -20,12 +26,37 @@ type VerificationCompleted struct {
 	Challenge string
 }
 
+// SignatureInvalid occurs when a callback with a missing or invalid
+// signature was received.
+type SignatureInvalid struct {
+	Signature    string
+	Signature256 string
+	Err          error
+}
+
+// Platform is the platform an event occurred on.
+type Platform string
+
+const (
+	// MessengerPlatform is Facebook Messenger.
+	MessengerPlatform Platform = "page"
+	// InstagramPlatform is Instagram Direct.
+	InstagramPlatform Platform = "instagram"
+)
+
 // Metadata contains informations about an occured event.
 type Metadata struct {
+	// Platform is the platform the event occurred on.
+	Platform Platform `json:"-"`
+	// PageID is the ID of the page, or of the Instagram account
+	// for events on the InstagramPlatform.
 	PageID      string `json:"-"`
 	SenderID    string `json:"-"`
 	RecipientID string `json:"-"`
 	Timestamp   int64  `json:"-"`
+	// Standby is set if the event was received on the standby channel,
+	// because the app doesn't control the conversation.
+	Standby bool `json:"-"`
 }
 
 // MessageReceived event occurs when a message has been sent to a page.
@@ -39,6 +70,14 @@ type MessageReceived struct {
 	QuickReply  *struct {
 		Payload string `json:"payload"`
 	} `json:"quick_reply"`
+	ReplyTo *struct {
+		MessageID string `json:"mid"`
+		// Story is set if the message is a reply to an Instagram story.
+		Story *struct {
+			ID  string `json:"id"`
+			URL string `json:"url"`
+		} `json:"story"`
+	} `json:"reply_to"`
 }
 
 // HasAttachments returns if the message contains attachments.
@@ -51,6 +90,37 @@ func (m *MessageReceived) IsQuickReply() bool {
 	return m.QuickReply != nil
 }
 
+// IsReply returns if the message quotes an earlier message.
+func (m *MessageReceived) IsReply() bool {
+	return m.ReplyTo != nil && m.ReplyTo.MessageID != ""
+}
+
+// IsStoryReply returns if the message is a reply to an Instagram story.
+func (m *MessageReceived) IsStoryReply() bool {
+	return m.ReplyTo != nil && m.ReplyTo.Story != nil
+}
+
+// MessageEchoed event occurs when a message has been sent by a page.
+// The sender of the event is the page, the recipient the user.
+type MessageEchoed struct {
+	Metadata
+	MessageID   string            `json:"mid"`
+	Seq         int               `json:"seq"`
+	Text        string            `json:"text"`
+	StickerID   int               `json:"sticker_id"`
+	Attachments []*AttachmentInfo `json:"attachments"`
+	// AppID is the ID of the app which sent the message.
+	// It's empty if the message was sent by a page admin.
+	AppID AppID `json:"app_id"`
+	// AppMetadata is the metadata set by the app on Message.Metadata.
+	AppMetadata string `json:"metadata"`
+}
+
+// HasAttachments returns if the message contains attachments.
+func (m *MessageEchoed) HasAttachments() bool {
+	return len(m.Attachments) > 0
+}
+
 // MessageDelivered event occurs when a message a page has sent has been delivered.
 type MessageDelivered struct {
 	Metadata
@@ -66,6 +136,26 @@ type MessageRead struct {
 	Seq       int `json:"seq"`
 }
 
+// ReactionAction defines if a reaction was added or removed.
+type ReactionAction string
+
+const (
+	// ReactionAdded indicates that a reaction was added to a message.
+	ReactionAdded ReactionAction = "react"
+	// ReactionRemoved indicates that a reaction was removed from a message.
+	ReactionRemoved ReactionAction = "unreact"
+)
+
+// MessageReacted event occurs when a user reacted to a message a page has sent.
+type MessageReacted struct {
+	Metadata
+	MessageID string         `json:"mid"`
+	Action    ReactionAction `json:"action"`
+	// Reaction is the name of the reaction, e.g. "smile" or "love".
+	Reaction string `json:"reaction"`
+	Emoji    string `json:"emoji"`
+}
+
 // PostbackReceived event occurs when a Postback button, Get Started button,
 // Persistent menu or Structured Message has been tapped.
 type PostbackReceived struct {
@@ -102,6 +192,124 @@ type OptInTapped struct {
 	Reference string `json:"ref"`
 }
 
+// ThreadControlPassed occurs when the control of a conversation
+// was passed to the app.
+type ThreadControlPassed struct {
+	Metadata
+	NewOwnerAppID      AppID `json:"new_owner_app_id"`
+	PreviousOwnerAppID AppID `json:"previous_owner_app_id"`
+	// AppMetadata is the metadata set by the app which passed the control.
+	AppMetadata string `json:"metadata"`
+}
+
+// ThreadControlTaken occurs when the primary receiver took the control
+// of a conversation from the app.
+type ThreadControlTaken struct {
+	Metadata
+	PreviousOwnerAppID AppID `json:"previous_owner_app_id"`
+	NewOwnerAppID      AppID `json:"new_owner_app_id"`
+	// AppMetadata is the metadata set by the app which took the control.
+	AppMetadata string `json:"metadata"`
+}
+
+// ThreadControlRequested occurs when a secondary receiver requested
+// the control of a conversation the app controls.
+type ThreadControlRequested struct {
+	Metadata
+	RequestedOwnerAppID AppID `json:"requested_owner_app_id"`
+	// AppMetadata is the metadata set by the app which requested the control.
+	AppMetadata string `json:"metadata"`
+}
+
+// AppRolesChanged occurs when the roles of the app for a page were changed.
+type AppRolesChanged struct {
+	Metadata
+	// Roles contains the roles, e.g. "primary_receiver", by app ID.
+	Roles map[AppID][]string
+}
+
+// FeedUser is the author of a post, comment or reaction on a page feed.
+type FeedUser struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Comment contains information about a comment on a page post.
+type Comment struct {
+	CommentID string   `json:"comment_id"`
+	PostID    string   `json:"post_id"`
+	ParentID  string   `json:"parent_id"`
+	From      FeedUser `json:"from"`
+	Message   string   `json:"message"`
+}
+
+// CommentAdded occurs when a comment was added to a page post.
+// Reply privately with CommentID as recipient.
+type CommentAdded struct {
+	Metadata
+	Comment
+}
+
+// CommentEdited occurs when a comment on a page post was edited.
+type CommentEdited struct {
+	Metadata
+	Comment
+}
+
+// CommentRemoved occurs when a comment on a page post was removed.
+type CommentRemoved struct {
+	Metadata
+	Comment
+}
+
+// Post contains information about a post on a page feed.
+type Post struct {
+	PostID string   `json:"post_id"`
+	From   FeedUser `json:"from"`
+	// Item is the type of the post, e.g. "status", "photo" or "video".
+	Item    string `json:"item"`
+	Message string `json:"message"`
+}
+
+// PostAdded occurs when a post was added to a page feed.
+type PostAdded struct {
+	Metadata
+	Post
+}
+
+// PostEdited occurs when a post on a page feed was edited.
+type PostEdited struct {
+	Metadata
+	Post
+}
+
+// PostRemoved occurs when a post on a page feed was removed.
+type PostRemoved struct {
+	Metadata
+	Post
+}
+
+// PostReacted occurs when a reaction on a page post or comment was
+// added, changed or removed.
+type PostReacted struct {
+	Metadata
+	PostID string `json:"post_id"`
+	// CommentID is set if the reaction is on a comment.
+	CommentID string   `json:"comment_id"`
+	ParentID  string   `json:"parent_id"`
+	From      FeedUser `json:"from"`
+	// Verb is either "add", "edit" or "remove".
+	Verb         string `json:"verb"`
+	ReactionType string `json:"reaction_type"`
+}
+
+// FeedChangeUnsupported occurs when an unknown page change was received.
+type FeedChangeUnsupported struct {
+	Metadata
+	Field string
+	Value json.RawMessage
+}
+
 // AttachmentInfo contains information about an attachment.
 type AttachmentInfo struct {
 	Type    string `json:"type"`
@@ -121,6 +329,18 @@ func (p *AttachmentInfo) IsLocation() bool {
 	return p.Payload.LocationPayload != nil
 }
 
+// IsStoryMention returns if the attachment is a mention in an Instagram story.
+// The URL of the story is contained in the multimedia payload.
+func (p *AttachmentInfo) IsStoryMention() bool {
+	return p.Type == "story_mention"
+}
+
+// IsShare returns if the attachment is a shared Instagram post.
+// The URL of the post is contained in the multimedia payload.
+func (p *AttachmentInfo) IsShare() bool {
+	return p.Type == "share"
+}
+
 // MultimediaPayload contains information about a multimedia file.
 type MultimediaPayload struct {
 	URL string `json:"url,omitempty"`
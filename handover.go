@@ -0,0 +1,120 @@
+package fbmessenger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// AppID is the ID of a Facebook app.
+// It's decoded from both JSON strings and numbers.
+type AppID string
+
+// UnmarshalJSON implements json.Unmarshaler interface.
+func (id *AppID) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*id = AppID(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*id = AppID(n)
+	return nil
+}
+
+// PassThreadControl passes the control of the conversation with the
+// recipient to the app with the given ID.
+func (s *Sender) PassThreadControl(ctx context.Context, to Recipient, targetAppID AppID, metadata string) error {
+	return s.threadControl(ctx, "pass_thread_control", to, map[string]interface{}{
+		"target_app_id": targetAppID,
+		"metadata":      metadata,
+	})
+}
+
+// TakeThreadControl takes the control of the conversation with the
+// recipient from the app which currently controls it.
+// Only the primary receiver can take the control.
+func (s *Sender) TakeThreadControl(ctx context.Context, to Recipient, metadata string) error {
+	return s.threadControl(ctx, "take_thread_control", to, map[string]interface{}{
+		"metadata": metadata,
+	})
+}
+
+// RequestThreadControl asks the primary receiver to pass the control
+// of the conversation with the recipient.
+func (s *Sender) RequestThreadControl(ctx context.Context, to Recipient, metadata string) error {
+	return s.threadControl(ctx, "request_thread_control", to, map[string]interface{}{
+		"metadata": metadata,
+	})
+}
+
+// ReleaseThreadControl releases the control of the conversation with the
+// recipient to the primary receiver.
+func (s *Sender) ReleaseThreadControl(ctx context.Context, to Recipient, metadata string) error {
+	return s.threadControl(ctx, "release_thread_control", to, map[string]interface{}{
+		"metadata": metadata,
+	})
+}
+
+func (s *Sender) threadControl(ctx context.Context, edge string, to Recipient, src map[string]interface{}) error {
+	recipient, err := to.Source()
+	if err != nil {
+		return err
+	}
+	src["recipient"] = recipient
+	if src["metadata"] == "" {
+		delete(src, "metadata")
+	}
+	return s.call(ctx, http.MethodPost, s.edgeURL(edge, nil), src, nil)
+}
+
+// ThreadOwner returns the ID of the app which controls the conversation
+// with the given user.
+func (s *Sender) ThreadOwner(ctx context.Context, psid string) (AppID, error) {
+	var resp struct {
+		Data []struct {
+			ThreadOwner struct {
+				AppID AppID `json:"app_id"`
+			} `json:"thread_owner"`
+		} `json:"data"`
+	}
+	u := s.edgeURL("thread_owner", url.Values{
+		"recipient": {psid},
+	})
+	if err := s.call(ctx, http.MethodGet, u, nil, &resp); err != nil {
+		return "", err
+	}
+	if len(resp.Data) == 0 {
+		return "", nil
+	}
+	return resp.Data[0].ThreadOwner.AppID, nil
+}
+
+// SecondaryReceiver is an app which is set as secondary receiver of a page.
+type SecondaryReceiver struct {
+	ID   AppID  `json:"id"`
+	Name string `json:"name"`
+}
+
+// SecondaryReceivers returns the apps which are set as secondary receivers.
+// Only the primary receiver can list them.
+func (s *Sender) SecondaryReceivers(ctx context.Context) ([]*SecondaryReceiver, error) {
+	var resp struct {
+		Data []*SecondaryReceiver `json:"data"`
+	}
+	u := s.edgeURL("secondary_receivers", url.Values{
+		"fields": {"id,name"},
+	})
+	if err := s.call(ctx, http.MethodGet, u, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
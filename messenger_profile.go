@@ -0,0 +1,378 @@
+package fbmessenger
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ProfileField is a field of the Messenger Profile.
+type ProfileField string
+
+// Messenger Profile fields.
+const (
+	GetStartedField         ProfileField = "get_started"
+	GreetingField           ProfileField = "greeting"
+	PersistentMenuField     ProfileField = "persistent_menu"
+	WhitelistedDomainsField ProfileField = "whitelisted_domains"
+	IceBreakersField        ProfileField = "ice_breakers"
+	HomeURLField            ProfileField = "home_url"
+	AccountLinkingURLField  ProfileField = "account_linking_url"
+)
+
+// ProfileFields contains all fields of the Messenger Profile.
+var ProfileFields = []ProfileField{
+	GetStartedField,
+	GreetingField,
+	PersistentMenuField,
+	WhitelistedDomainsField,
+	IceBreakersField,
+	HomeURLField,
+	AccountLinkingURLField,
+}
+
+// MessengerProfile represents the page level configuration of a bot.
+// Only fields which are set are sent to the Messenger Profile API.
+type MessengerProfile struct {
+	GetStarted         *GetStarted
+	Greeting           []*Greeting
+	PersistentMenu     []*PersistentMenu
+	WhitelistedDomains []string
+	IceBreakers        []*IceBreaker
+	HomeURL            *HomeURL
+	AccountLinkingURL  string
+
+	// received contains the fields as received from the Messenger Profile
+	// API, including the ones the decoded fields don't cover.
+	received map[ProfileField]json.RawMessage
+}
+
+// Source implements Object interface.
+func (p *MessengerProfile) Source() (interface{}, error) {
+	src := map[string]interface{}{}
+	if p.GetStarted != nil {
+		src[string(GetStartedField)] = map[string]interface{}{
+			"payload": p.GetStarted.Payload,
+		}
+	}
+	if len(p.Greeting) > 0 {
+		var greetings []interface{}
+		for _, g := range p.Greeting {
+			greetings = append(greetings, map[string]interface{}{
+				"locale": g.Locale,
+				"text":   g.Text,
+			})
+		}
+		src[string(GreetingField)] = greetings
+	}
+	if len(p.PersistentMenu) > 0 {
+		var menus []interface{}
+		for _, m := range p.PersistentMenu {
+			menuSrc, err := m.Source()
+			if err != nil {
+				return nil, err
+			}
+			menus = append(menus, menuSrc)
+		}
+		src[string(PersistentMenuField)] = menus
+	}
+	if len(p.WhitelistedDomains) > 0 {
+		src[string(WhitelistedDomainsField)] = p.WhitelistedDomains
+	}
+	if len(p.IceBreakers) > 0 {
+		var iceBreakers []interface{}
+		for _, ib := range p.IceBreakers {
+			iceBreakers = append(iceBreakers, map[string]interface{}{
+				"question": ib.Question,
+				"payload":  ib.Payload,
+			})
+		}
+		src[string(IceBreakersField)] = iceBreakers
+	}
+	if p.HomeURL != nil {
+		homeSrc, err := p.HomeURL.Source()
+		if err != nil {
+			return nil, err
+		}
+		src[string(HomeURLField)] = homeSrc
+	}
+	if p.AccountLinkingURL != "" {
+		src[string(AccountLinkingURLField)] = p.AccountLinkingURL
+	}
+	return src, nil
+}
+
+// MarshalJSON encodes the profile in the format of the Messenger Profile API.
+func (p *MessengerProfile) MarshalJSON() ([]byte, error) {
+	src, err := p.Source()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(src)
+}
+
+// UnmarshalJSON decodes a profile in the format of the Messenger Profile API.
+func (p *MessengerProfile) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		GetStarted     *GetStarted `json:"get_started"`
+		Greeting       []*Greeting `json:"greeting"`
+		PersistentMenu []struct {
+			Locale                string            `json:"locale"`
+			ComposerInputDisabled bool              `json:"composer_input_disabled"`
+			CallToActions         []json.RawMessage `json:"call_to_actions"`
+		} `json:"persistent_menu"`
+		WhitelistedDomains []string      `json:"whitelisted_domains"`
+		IceBreakers        []*IceBreaker `json:"ice_breakers"`
+		HomeURL            *HomeURL      `json:"home_url"`
+		AccountLinkingURL  string        `json:"account_linking_url"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	var received map[ProfileField]json.RawMessage
+	if err := json.Unmarshal(data, &received); err != nil {
+		return err
+	}
+
+	*p = MessengerProfile{
+		GetStarted:         raw.GetStarted,
+		Greeting:           raw.Greeting,
+		WhitelistedDomains: raw.WhitelistedDomains,
+		IceBreakers:        raw.IceBreakers,
+		HomeURL:            raw.HomeURL,
+		AccountLinkingURL:  raw.AccountLinkingURL,
+		received:           received,
+	}
+	for _, m := range raw.PersistentMenu {
+		btns, err := decodeButtons(m.CallToActions)
+		if err != nil {
+			return err
+		}
+		p.PersistentMenu = append(p.PersistentMenu, &PersistentMenu{
+			Locale:                m.Locale,
+			ComposerInputDisabled: m.ComposerInputDisabled,
+			CallToActions:         btns,
+		})
+	}
+	return nil
+}
+
+// GetStarted represents the Get Started button.
+type GetStarted struct {
+	Payload string `json:"payload"`
+}
+
+// Greeting represents the greeting text of a locale.
+// The locale "default" is used if no greeting matches the user's locale.
+type Greeting struct {
+	Locale string `json:"locale"`
+	Text   string `json:"text"`
+}
+
+// PersistentMenu represents the persistent menu of a locale.
+// The locale "default" is used if no menu matches the user's locale.
+type PersistentMenu struct {
+	Locale                string
+	ComposerInputDisabled bool
+	CallToActions         []Button
+}
+
+// Source implements Object interface.
+func (m *PersistentMenu) Source() (interface{}, error) {
+	src := map[string]interface{}{
+		"locale": m.Locale,
+	}
+	if m.ComposerInputDisabled {
+		src["composer_input_disabled"] = true
+	}
+	var btnSrcs []interface{}
+	for _, btn := range m.CallToActions {
+		btnSrc, err := btn.Source()
+		if err != nil {
+			return nil, err
+		}
+		btnSrcs = append(btnSrcs, btnSrc)
+	}
+	src["call_to_actions"] = btnSrcs
+	return src, nil
+}
+
+// NestedButton represents a submenu of the persistent menu.
+type NestedButton struct {
+	Title         string
+	CallToActions []Button
+}
+
+// Source implements Object interface.
+func (b *NestedButton) Source() (interface{}, error) {
+	var btnSrcs []interface{}
+	for _, btn := range b.CallToActions {
+		btnSrc, err := btn.Source()
+		if err != nil {
+			return nil, err
+		}
+		btnSrcs = append(btnSrcs, btnSrc)
+	}
+	return map[string]interface{}{
+		"type":            "nested",
+		"title":           b.Title,
+		"call_to_actions": btnSrcs,
+	}, nil
+}
+
+func (b *NestedButton) isButton() {}
+
+// IceBreaker represents a question a user can tap to start a conversation.
+type IceBreaker struct {
+	Question string `json:"question"`
+	Payload  string `json:"payload"`
+}
+
+// HomeURL represents the Chat Extension opened from the composer.
+type HomeURL struct {
+	URL                string             `json:"url"`
+	WebviewHeightRatio WebviewHeightRatio `json:"webview_height_ratio"`
+	// WebviewShareButton is either "show" or "hide".
+	WebviewShareButton string `json:"webview_share_button,omitempty"`
+	InTest             bool   `json:"in_test"`
+}
+
+// Source implements Object interface.
+func (h *HomeURL) Source() (interface{}, error) {
+	src := map[string]interface{}{
+		"url":                  h.URL,
+		"webview_height_ratio": h.WebviewHeightRatio,
+		"in_test":              h.InTest,
+	}
+	if h.WebviewShareButton != "" {
+		src["webview_share_button"] = h.WebviewShareButton
+	}
+	return src, nil
+}
+
+// rawButton represents a button of an unknown type. It's encoded
+// unchanged, so setting a fetched profile keeps it.
+type rawButton json.RawMessage
+
+// Source implements Object interface.
+func (b rawButton) Source() (interface{}, error) {
+	return json.RawMessage(b), nil
+}
+
+func (b rawButton) isButton() {}
+
+// decodeButtons decodes buttons by their type.
+func decodeButtons(raws []json.RawMessage) ([]Button, error) {
+	var btns []Button
+	for _, raw := range raws {
+		btn, err := decodeButton(raw)
+		if err != nil {
+			return nil, err
+		}
+		btns = append(btns, btn)
+	}
+	return btns, nil
+}
+
+// decodeButton decodes a button by its type.
+// Buttons of unknown types are kept as rawButton.
+func decodeButton(raw json.RawMessage) (Button, error) {
+	var b struct {
+		Type          string            `json:"type"`
+		Title         string            `json:"title"`
+		URL           string            `json:"url"`
+		Payload       string            `json:"payload"`
+		CallToActions []json.RawMessage `json:"call_to_actions"`
+	}
+	if err := json.Unmarshal(raw, &b); err != nil {
+		return nil, err
+	}
+	switch b.Type {
+	case "web_url":
+		var btn URLButton
+		if err := json.Unmarshal(raw, &btn); err != nil {
+			return nil, err
+		}
+		return &btn, nil
+	case "postback":
+		return &PostbackButton{Title: b.Title, Payload: b.Payload}, nil
+	case "phone_number":
+		return &CallButton{Title: b.Title, PhoneNumber: b.Payload}, nil
+	case "element_share":
+		return &ShareButton{}, nil
+	case "account_link":
+		return &AccountLinkButton{URL: b.URL}, nil
+	case "account_unlink":
+		return &AccountUnlinkButton{}, nil
+	case "nested":
+		btns, err := decodeButtons(b.CallToActions)
+		if err != nil {
+			return nil, err
+		}
+		return &NestedButton{Title: b.Title, CallToActions: btns}, nil
+	}
+	return rawButton(raw), nil
+}
+
+func joinProfileFields(fields []ProfileField) string {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = string(f)
+	}
+	return strings.Join(names, ",")
+}
+
+// GetMessengerProfile returns the given fields of the Messenger Profile.
+// All fields are returned if none are given.
+func (s *Sender) GetMessengerProfile(ctx context.Context, fields ...ProfileField) (*MessengerProfile, error) {
+	if len(fields) == 0 {
+		fields = ProfileFields
+	}
+	var resp struct {
+		Data []*MessengerProfile `json:"data"`
+	}
+	u := s.profileURL(url.Values{
+		"fields": {joinProfileFields(fields)},
+	})
+	if err := s.call(ctx, http.MethodGet, u, nil, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Data) == 0 {
+		return &MessengerProfile{}, nil
+	}
+	return resp.Data[0], nil
+}
+
+// SetMessengerProfile sets the fields of the Messenger Profile which are
+// set in p. Other fields are left unchanged.
+func (s *Sender) SetMessengerProfile(ctx context.Context, p *MessengerProfile) error {
+	src, err := p.Source()
+	if err != nil {
+		return err
+	}
+	return s.call(ctx, http.MethodPost, s.profileURL(nil), src, nil)
+}
+
+// DeleteMessengerProfile deletes the given fields of the Messenger Profile.
+func (s *Sender) DeleteMessengerProfile(ctx context.Context, fields ...ProfileField) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	return s.call(ctx, http.MethodDelete, s.profileURL(nil), map[string]interface{}{
+		"fields": fields,
+	}, nil)
+}
+
+// profileURL returns the URL of the Messenger Profile of the platform
+// the Sender sends messages on, with the given query parameters added.
+func (s *Sender) profileURL(params url.Values) string {
+	if s.Platform() == InstagramPlatform {
+		if params == nil {
+			params = url.Values{}
+		}
+		params.Set("platform", string(InstagramPlatform))
+	}
+	return s.edgeURL("messenger_profile", params)
+}
@@ -0,0 +1,204 @@
+package fbmessenger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// ProfileChangeAction defines how a field of the Messenger Profile is changed.
+type ProfileChangeAction string
+
+const (
+	// ProfileFieldSet sets the field to the desired value.
+	ProfileFieldSet ProfileChangeAction = "set"
+	// ProfileFieldDelete deletes the field.
+	ProfileFieldDelete ProfileChangeAction = "delete"
+)
+
+// ProfileChange describes the change of a field of the Messenger Profile.
+type ProfileChange struct {
+	Field  ProfileField        `json:"field"`
+	Action ProfileChangeAction `json:"action"`
+	// Current is the current value of the field, nil if it isn't set.
+	Current json.RawMessage `json:"current,omitempty"`
+	// Desired is the desired value of the field, nil if it's deleted.
+	Desired json.RawMessage `json:"desired,omitempty"`
+}
+
+// DiffMessengerProfile returns the changes required to turn the current
+// into the desired profile. Fields not set in desired are deleted.
+// Fields of a current profile returned by GetMessengerProfile are compared
+// as received, so buttons and fields the package doesn't know are taken
+// into account.
+func DiffMessengerProfile(current, desired *MessengerProfile) ([]*ProfileChange, error) {
+	currentFields, err := profileFields(current)
+	if err != nil {
+		return nil, err
+	}
+	for field, v := range current.received {
+		currentFields[field] = v
+	}
+	desiredFields, err := profileFields(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []*ProfileChange
+	for _, field := range ProfileFields {
+		cur, want := currentFields[field], desiredFields[field]
+		if cur != nil && isZeroJSON(cur) {
+			cur = nil
+		}
+		switch {
+		case want == nil && cur == nil:
+		case want == nil:
+			changes = append(changes, &ProfileChange{
+				Field:   field,
+				Action:  ProfileFieldDelete,
+				Current: cur,
+			})
+		case !equalJSON(cur, want):
+			changes = append(changes, &ProfileChange{
+				Field:   field,
+				Action:  ProfileFieldSet,
+				Current: cur,
+				Desired: want,
+			})
+		}
+	}
+	return changes, nil
+}
+
+// profileFields returns the JSON encoded value of every field set in p.
+func profileFields(p *MessengerProfile) (map[ProfileField]json.RawMessage, error) {
+	src, err := p.Source()
+	if err != nil {
+		return nil, err
+	}
+	fields := map[ProfileField]json.RawMessage{}
+	for name, v := range src.(map[string]interface{}) {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		fields[ProfileField(name)] = b
+	}
+	return fields, nil
+}
+
+// equalJSON returns if a and b encode the same value. Object members with
+// a zero value are equal to missing ones, as the Messenger Profile API
+// returns the defaults of fields which weren't set.
+func equalJSON(a, b json.RawMessage) bool {
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return bytes.Equal(a, b)
+	}
+	return equalJSONValue(va, vb)
+}
+
+func equalJSONValue(a, b interface{}) bool {
+	if isZeroJSONValue(a) && isZeroJSONValue(b) {
+		return true
+	}
+	switch a := a.(type) {
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for k, v := range a {
+			if !equalJSONValue(v, b[k]) {
+				return false
+			}
+		}
+		for k, v := range b {
+			if _, ok := a[k]; !ok && !isZeroJSONValue(v) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equalJSONValue(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+func isZeroJSON(v json.RawMessage) bool {
+	var i interface{}
+	return json.Unmarshal(v, &i) == nil && isZeroJSONValue(i)
+}
+
+func isZeroJSONValue(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case string:
+		return v == ""
+	case float64:
+		return v == 0
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// ApplyMessengerProfile fetches the current Messenger Profile and changes
+// the fields which differ from desired. Fields not set in desired are deleted.
+// The changes are returned; if dryRun is set, they aren't applied.
+// If applying fails, the changes which were applied are returned with the error.
+func (s *Sender) ApplyMessengerProfile(ctx context.Context, desired *MessengerProfile, dryRun bool) ([]*ProfileChange, error) {
+	current, err := s.GetMessengerProfile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := DiffMessengerProfile(current, desired)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun || len(changes) == 0 {
+		return changes, nil
+	}
+
+	set := map[string]json.RawMessage{}
+	var deleted []ProfileField
+	for _, c := range changes {
+		if c.Action == ProfileFieldDelete {
+			deleted = append(deleted, c.Field)
+		} else {
+			set[string(c.Field)] = c.Desired
+		}
+	}
+	// set fields first, as some fields can't be deleted while
+	// others depend on them, e.g. the persistent menu on get_started
+	var applied []*ProfileChange
+	if len(set) > 0 {
+		if err := s.call(ctx, http.MethodPost, s.profileURL(nil), set, nil); err != nil {
+			return nil, err
+		}
+		for _, c := range changes {
+			if c.Action == ProfileFieldSet {
+				applied = append(applied, c)
+			}
+		}
+	}
+	if err := s.DeleteMessengerProfile(ctx, deleted...); err != nil {
+		return applied, err
+	}
+	return changes, nil
+}
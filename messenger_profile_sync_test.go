@@ -0,0 +1,87 @@
+package fbmessenger
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+const liveProfile = `{"data":[{"get_started":{"payload":"START"},"persistent_menu":[{"locale":"default","composer_input_disabled":false,"call_to_actions":[` +
+	`{"type":"postback","title":"Help","payload":"HELP"},{"type":"game_play","title":"Play"}]}]}]}`
+
+func newProfileServer(t *testing.T, deleteStatus int) (*Sender, *[]string) {
+	t.Helper()
+	var calls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method)
+		io.Copy(io.Discard, r.Body)
+		switch r.Method {
+		case http.MethodGet:
+			io.WriteString(w, liveProfile)
+		case http.MethodDelete:
+			w.WriteHeader(deleteStatus)
+			if deleteStatus != http.StatusOK {
+				io.WriteString(w, `{"error":{"message":"Invalid parameter","type":"OAuthException","code":100}}`)
+				return
+			}
+			io.WriteString(w, `{"result":"success"}`)
+		default:
+			io.WriteString(w, `{"result":"success"}`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	u, _ := url.Parse(srv.URL + "/v2.6/me/messages")
+	s, err := NewSender("token", Endpoint(u))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, &calls
+}
+
+func TestApplyMessengerProfileUnknownButton(t *testing.T) {
+	s, calls := newProfileServer(t, http.StatusOK)
+	desired := &MessengerProfile{
+		GetStarted: &GetStarted{Payload: "START"},
+		PersistentMenu: []*PersistentMenu{{
+			Locale:        "default",
+			CallToActions: []Button{&PostbackButton{Title: "Help", Payload: "HELP"}},
+		}},
+	}
+	changes, err := s.ApplyMessengerProfile(context.Background(), desired, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Field != PersistentMenuField || changes[0].Action != ProfileFieldSet {
+		t.Fatalf("expected the persistent menu to be set, got %+v", changes)
+	}
+
+	// the live menu doesn't differ by the default of composer_input_disabled
+	desired.PersistentMenu[0].CallToActions = append(desired.PersistentMenu[0].CallToActions, rawButton(`{"title":"Play","type":"game_play"}`))
+	changes, err = s.ApplyMessengerProfile(context.Background(), desired, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("expected no changes, got %+v", changes)
+	}
+	if len(*calls) != 2 {
+		t.Errorf("expected only fetches, got %v", *calls)
+	}
+}
+
+func TestApplyMessengerProfileDeleteFailed(t *testing.T) {
+	s, _ := newProfileServer(t, http.StatusBadRequest)
+	desired := &MessengerProfile{
+		GetStarted: &GetStarted{Payload: "RESTART"},
+	}
+	changes, err := s.ApplyMessengerProfile(context.Background(), desired, false)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if len(changes) != 1 || changes[0].Field != GetStartedField {
+		t.Fatalf("expected the applied get_started change, got %+v", changes)
+	}
+}
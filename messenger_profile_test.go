@@ -0,0 +1,41 @@
+package fbmessenger
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMessengerProfileKeepsUnknownButtons(t *testing.T) {
+	data := `{"persistent_menu":[{"locale":"default","call_to_actions":[` +
+		`{"type":"postback","title":"Help","payload":"HELP"},` +
+		`{"type":"game_play","title":"Play","game_metadata":{"player_id":"42"}}]}]}`
+	var p MessengerProfile
+	if err := json.Unmarshal([]byte(data), &p); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(p.PersistentMenu[0].CallToActions); n != 2 {
+		t.Fatalf("expected 2 buttons, got %d", n)
+	}
+
+	b, err := json.Marshal(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, want interface{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	json.Unmarshal([]byte(data), &want)
+	if gb, wb := mustMarshal(t, got), mustMarshal(t, want); gb != wb {
+		t.Errorf("expected %s, got %s", wb, gb)
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
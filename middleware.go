@@ -0,0 +1,116 @@
+package fbmessenger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+)
+
+// Middleware wraps a Handler to add behavior around the handling of events.
+// A Middleware may pass a derived context to the next Handler,
+// e.g. to provide values to the handlers after it.
+type Middleware func(next Handler) Handler
+
+// Chain returns h wrapped by the given middlewares.
+// The first middleware is the outermost one.
+func Chain(h Handler, mws ...Middleware) Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+// eventMetadata returns the metadata of events which have one.
+func eventMetadata(e Event) (Metadata, bool) {
+	if m, ok := e.(interface{ metadata() Metadata }); ok {
+		return m.metadata(), true
+	}
+	return Metadata{}, false
+}
+
+func (md Metadata) metadata() Metadata {
+	return md
+}
+
+// Recover returns a Middleware which recovers from panics of the next
+// Handler and logs them with their stack trace to the given logger.
+// slog.Default is used if logger is nil.
+func Recover(logger *slog.Logger) Middleware {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, e Event) {
+			defer func() {
+				if v := recover(); v != nil {
+					logger.ErrorContext(ctx, "panic while handling event",
+						slog.String("event", fmt.Sprintf("%T", e)),
+						slog.Any("panic", v),
+						slog.String("stack", string(debug.Stack())),
+					)
+				}
+			}()
+			next.HandleEvent(ctx, e)
+		})
+	}
+}
+
+// markSeenTimeout limits the duration of a mark_seen call of AutoMarkSeen.
+const markSeenTimeout = 10 * time.Second
+
+// AutoMarkSeen returns a Middleware which marks received messages as seen
+// with the given Sender. Messages received on the standby channel aren't
+// marked. The mark_seen call is sent asynchronously, so it doesn't delay
+// the handling of the message or the webhook response, and is bounded by
+// a timeout instead of the context of the event. Errors of the Sender
+// are passed to onError, if it's not nil.
+func AutoMarkSeen(s *Sender, onError func(ctx context.Context, e Event, err error)) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, e Event) {
+			if msg, ok := e.(*MessageReceived); ok && !msg.Standby {
+				// the event's context may be canceled once the webhook
+				// request is answered, before the call completes
+				sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), markSeenTimeout)
+				go func() {
+					defer cancel()
+					if err := s.SendAction(sendCtx, User(msg.SenderID), MarkSeen); err != nil && onError != nil {
+						onError(sendCtx, e, err)
+					}
+				}()
+			}
+			next.HandleEvent(ctx, e)
+		})
+	}
+}
+
+// Logging returns a Middleware which logs every handled event with
+// its metadata and the duration of its handling to the given logger.
+// slog.Default is used if logger is nil.
+func Logging(logger *slog.Logger) Middleware {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, e Event) {
+			start := time.Now()
+			next.HandleEvent(ctx, e)
+
+			attrs := []slog.Attr{
+				slog.String("event", fmt.Sprintf("%T", e)),
+				slog.Duration("duration", time.Since(start)),
+			}
+			if md, ok := eventMetadata(e); ok {
+				attrs = append(attrs,
+					slog.String("platform", string(md.Platform)),
+					slog.String("page_id", md.PageID),
+					slog.String("sender_id", md.SenderID),
+					slog.String("recipient_id", md.RecipientID),
+					slog.Bool("standby", md.Standby),
+				)
+			}
+			logger.LogAttrs(ctx, slog.LevelInfo, "handled event", attrs...)
+		})
+	}
+}
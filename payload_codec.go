@@ -0,0 +1,128 @@
+package fbmessenger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// MaxPayloadLength is the maximum length of a button or Quick Reply payload.
+const MaxPayloadLength = 1000
+
+var (
+	// ErrPayloadTooLong indicates that a payload exceeds MaxPayloadLength.
+	ErrPayloadTooLong = errors.New("payload exceeds 1000 characters")
+	// ErrPayloadInvalid indicates that a payload wasn't encoded by the PayloadCodec.
+	ErrPayloadInvalid = errors.New("payload invalid")
+	// ErrPayloadSignatureInvalid indicates that the signature of a payload is missing or doesn't match.
+	ErrPayloadSignatureInvalid = errors.New("payload signature invalid")
+	// ErrPayloadExpired indicates that a payload has expired.
+	ErrPayloadExpired = errors.New("payload expired")
+	// ErrPayloadMissing indicates that an event carries no payload.
+	ErrPayloadMissing = errors.New("payload missing")
+)
+
+// PayloadCodec encodes values into compact payloads for PostbackButton
+// and QuickReply and decodes them from the received events.
+// Payloads are base64 encoded JSON, optionally signed and with an expiry.
+type PayloadCodec struct {
+	// Prefix is prepended to encoded payloads, e.g. to route
+	// them with PayloadRouter.Prefix.
+	Prefix string
+	// Secret enables signing payloads with HMAC-SHA256, so tampered
+	// payloads are rejected. Payloads without a signature are rejected
+	// as well if it's set.
+	Secret []byte
+	// TTL is the duration payloads are valid for. Payloads don't expire if it's zero.
+	TTL time.Duration
+}
+
+// payloadEnvelope is the encoded content of a payload.
+type payloadEnvelope struct {
+	Data      json.RawMessage `json:"d"`
+	ExpiresAt int64           `json:"e,omitempty"`
+}
+
+// Encode encodes v as JSON into a payload.
+func (c *PayloadCodec) Encode(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	env := payloadEnvelope{Data: data}
+	if c.TTL > 0 {
+		env.ExpiresAt = time.Now().Add(c.TTL).Unix()
+	}
+	b, err := json.Marshal(env)
+	if err != nil {
+		return "", err
+	}
+
+	payload := c.Prefix + base64.RawURLEncoding.EncodeToString(b)
+	if c.Secret != nil {
+		payload += "." + base64.RawURLEncoding.EncodeToString(c.sign(payload))
+	}
+	if len(payload) > MaxPayloadLength {
+		return "", ErrPayloadTooLong
+	}
+	return payload, nil
+}
+
+// Decode verifies the payload and decodes it into v.
+func (c *PayloadCodec) Decode(payload string, v interface{}) error {
+	if len(payload) > MaxPayloadLength {
+		return ErrPayloadTooLong
+	}
+	if !strings.HasPrefix(payload, c.Prefix) {
+		return ErrPayloadInvalid
+	}
+
+	body := payload
+	if c.Secret != nil {
+		i := strings.LastIndexByte(payload, '.')
+		if i < 0 {
+			return ErrPayloadSignatureInvalid
+		}
+		sig, err := base64.RawURLEncoding.DecodeString(payload[i+1:])
+		if err != nil {
+			return ErrPayloadSignatureInvalid
+		}
+		body = payload[:i]
+		if !hmac.Equal(c.sign(body), sig) {
+			return ErrPayloadSignatureInvalid
+		}
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(body[len(c.Prefix):])
+	if err != nil {
+		return ErrPayloadInvalid
+	}
+	var env payloadEnvelope
+	if err := json.Unmarshal(b, &env); err != nil {
+		return ErrPayloadInvalid
+	}
+	if env.ExpiresAt > 0 && time.Now().Unix() > env.ExpiresAt {
+		return ErrPayloadExpired
+	}
+	return json.Unmarshal(env.Data, v)
+}
+
+// DecodeEvent decodes the payload of a PostbackReceived event or a
+// MessageReceived event of a tapped Quick Reply button into v.
+func (c *PayloadCodec) DecodeEvent(e Event, v interface{}) error {
+	payload, ok := eventPayload(e)
+	if !ok {
+		return ErrPayloadMissing
+	}
+	return c.Decode(payload, v)
+}
+
+func (c *PayloadCodec) sign(s string) []byte {
+	mac := hmac.New(sha256.New, c.Secret)
+	mac.Write([]byte(s))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,107 @@
+package fbmessenger
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testOrder struct {
+	ID     int    `json:"id"`
+	Action string `json:"action"`
+}
+
+func TestPayloadCodecRoundTrip(t *testing.T) {
+	codecs := map[string]*PayloadCodec{
+		"plain":  {},
+		"prefix": {Prefix: "ORDER:"},
+		"signed": {Prefix: "ORDER:", Secret: []byte("secret"), TTL: time.Hour},
+	}
+	for name, c := range codecs {
+		payload, err := c.Encode(testOrder{ID: 42, Action: "cancel"})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if !strings.HasPrefix(payload, c.Prefix) {
+			t.Errorf("%s: expected prefix %q, got %q", name, c.Prefix, payload)
+		}
+		var o testOrder
+		if err := c.DecodeEvent(&PostbackReceived{Payload: payload}, &o); err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if o.ID != 42 || o.Action != "cancel" {
+			t.Errorf("%s: unexpected order %+v", name, o)
+		}
+	}
+}
+
+func TestPayloadCodecTampered(t *testing.T) {
+	c := &PayloadCodec{Secret: []byte("secret")}
+	payload, err := c.Encode(testOrder{ID: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := strings.LastIndexByte(payload, '.')
+	forged, err := (&PayloadCodec{}).Encode(testOrder{ID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"forged data":         forged + payload[i:],
+		"missing signature":   payload[:i],
+		"invalid signature":   payload[:i] + ".!!!",
+		"other secret":        signPayload(t, &PayloadCodec{Secret: []byte("other")}, payload[:i]),
+		"truncated signature": payload[:len(payload)-2],
+	}
+	for name, p := range tests {
+		var o testOrder
+		if err := c.Decode(p, &o); err != ErrPayloadSignatureInvalid {
+			t.Errorf("%s: expected %v, got %v", name, ErrPayloadSignatureInvalid, err)
+		}
+	}
+}
+
+func signPayload(t *testing.T, c *PayloadCodec, body string) string {
+	t.Helper()
+	return body + "." + base64.RawURLEncoding.EncodeToString(c.sign(body))
+}
+
+func TestPayloadCodecExpired(t *testing.T) {
+	c := &PayloadCodec{Secret: []byte("secret")}
+	body := base64.RawURLEncoding.EncodeToString([]byte(`{"d":{"id":42},"e":1}`))
+	var o testOrder
+	if err := c.Decode(signPayload(t, c, body), &o); err != ErrPayloadExpired {
+		t.Fatalf("expected %v, got %v", ErrPayloadExpired, err)
+	}
+
+	c.TTL = time.Hour
+	payload, err := c.Encode(testOrder{ID: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Decode(payload, &o); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestPayloadCodecErrors(t *testing.T) {
+	c := &PayloadCodec{Prefix: "ORDER:"}
+	if _, err := c.Encode(strings.Repeat("a", MaxPayloadLength)); err != ErrPayloadTooLong {
+		t.Errorf("expected %v on encode, got %v", ErrPayloadTooLong, err)
+	}
+	var o testOrder
+	if err := c.Decode("ORDER:"+strings.Repeat("a", MaxPayloadLength), &o); err != ErrPayloadTooLong {
+		t.Errorf("expected %v on decode, got %v", ErrPayloadTooLong, err)
+	}
+	if err := c.Decode("OTHER:e30", &o); err != ErrPayloadInvalid {
+		t.Errorf("expected %v for other prefix, got %v", ErrPayloadInvalid, err)
+	}
+	if err := c.Decode("ORDER:!!!", &o); err != ErrPayloadInvalid {
+		t.Errorf("expected %v for invalid encoding, got %v", ErrPayloadInvalid, err)
+	}
+	if err := c.DecodeEvent(&MessageReceived{Text: "hi"}, &o); err != ErrPayloadMissing {
+		t.Errorf("expected %v for event without payload, got %v", ErrPayloadMissing, err)
+	}
+}
@@ -0,0 +1,159 @@
+package fbmessenger
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// PayloadParams contains the parameters extracted from a payload by name.
+type PayloadParams map[string]string
+
+// A PayloadHandler handles an event by its payload.
+type PayloadHandler func(ctx context.Context, params PayloadParams, e Event)
+
+// PayloadRouter dispatches PostbackReceived events and MessageReceived
+// events of tapped Quick Reply buttons to handlers by their payload.
+// Payloads are matched exactly first, then by pattern in the order the
+// patterns were registered and at last by the longest matching prefix.
+// Events without a matching handler or payload are given to the
+// NotFound handler. Handlers must be registered before events are dispatched.
+type PayloadRouter struct {
+	exact    map[string]PayloadHandler
+	patterns []*payloadPattern
+	prefixes []*payloadPrefix
+	notFound Handler
+}
+
+type payloadPattern struct {
+	re      *regexp.Regexp
+	handler PayloadHandler
+}
+
+type payloadPrefix struct {
+	prefix  string
+	handler PayloadHandler
+}
+
+// NewPayloadRouter creates a new PayloadRouter.
+func NewPayloadRouter() *PayloadRouter {
+	return &PayloadRouter{
+		exact: map[string]PayloadHandler{},
+	}
+}
+
+// Exact registers the handler for the given payload.
+func (r *PayloadRouter) Exact(payload string, h PayloadHandler) {
+	r.exact[payload] = h
+}
+
+// Prefix registers the handler for payloads starting with the given prefix.
+// The remainder of the payload is passed as parameter "*".
+func (r *PayloadRouter) Prefix(prefix string, h PayloadHandler) {
+	r.prefixes = append(r.prefixes, &payloadPrefix{
+		prefix:  prefix,
+		handler: h,
+	})
+	// keep the longest prefix first
+	sort.SliceStable(r.prefixes, func(i, j int) bool {
+		return len(r.prefixes[i].prefix) > len(r.prefixes[j].prefix)
+	})
+}
+
+// Pattern registers the handler for payloads matching the given pattern.
+// A pattern contains named parameters in braces, which match any
+// non-empty text, e.g. "ORDER:{id}:CANCEL". It panics if the pattern is invalid.
+func (r *PayloadRouter) Pattern(pattern string, h PayloadHandler) {
+	re, err := compilePayloadPattern(pattern)
+	if err != nil {
+		panic(err)
+	}
+	r.patterns = append(r.patterns, &payloadPattern{
+		re:      re,
+		handler: h,
+	})
+}
+
+var payloadParamName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func compilePayloadPattern(pattern string) (*regexp.Regexp, error) {
+	var expr strings.Builder
+	expr.WriteString("^")
+	rest := pattern
+	for {
+		i := strings.IndexByte(rest, '{')
+		if i < 0 {
+			expr.WriteString(regexp.QuoteMeta(rest))
+			break
+		}
+		j := strings.IndexByte(rest[i:], '}')
+		if j < 0 {
+			return nil, fmt.Errorf("payload pattern %q: unclosed parameter", pattern)
+		}
+		name := rest[i+1 : i+j]
+		if !payloadParamName.MatchString(name) {
+			return nil, fmt.Errorf("payload pattern %q: invalid parameter name %q", pattern, name)
+		}
+		expr.WriteString(regexp.QuoteMeta(rest[:i]))
+		expr.WriteString("(?P<" + name + ">.+?)")
+		rest = rest[i+j+1:]
+	}
+	expr.WriteString("$")
+	return regexp.Compile(expr.String())
+}
+
+// NotFound registers the handler for events without a matching handler or payload.
+func (r *PayloadRouter) NotFound(h Handler) {
+	r.notFound = h
+}
+
+// HandleEvent implements Handler interface.
+func (r *PayloadRouter) HandleEvent(ctx context.Context, e Event) {
+	if payload, ok := eventPayload(e); ok {
+		if h, ok := r.exact[payload]; ok {
+			h(ctx, PayloadParams{}, e)
+			return
+		}
+		for _, p := range r.patterns {
+			m := p.re.FindStringSubmatch(payload)
+			if m == nil {
+				continue
+			}
+			params := PayloadParams{}
+			for i, name := range p.re.SubexpNames() {
+				if name != "" {
+					params[name] = m[i]
+				}
+			}
+			p.handler(ctx, params, e)
+			return
+		}
+		for _, p := range r.prefixes {
+			if strings.HasPrefix(payload, p.prefix) {
+				p.handler(ctx, PayloadParams{
+					"*": payload[len(p.prefix):],
+				}, e)
+				return
+			}
+		}
+	}
+	if r.notFound != nil {
+		r.notFound.HandleEvent(ctx, e)
+	}
+}
+
+// eventPayload returns the payload of a PostbackReceived event
+// or a MessageReceived event of a tapped Quick Reply button.
+func eventPayload(e Event) (string, bool) {
+	switch evt := e.(type) {
+	case *PostbackReceived:
+		return evt.Payload, true
+	case *MessageReceived:
+		if evt.IsQuickReply() {
+			return evt.QuickReply.Payload, true
+		}
+	}
+	return "", false
+}
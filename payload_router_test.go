@@ -0,0 +1,98 @@
+package fbmessenger
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCompilePayloadPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		payload string
+		match   bool
+		params  map[string]string
+	}{
+		{"ORDER:{id}:CANCEL", "ORDER:42:CANCEL", true, map[string]string{"id": "42"}},
+		{"ORDER:{id}:CANCEL", "ORDER::CANCEL", false, nil},
+		{"ORDER:{id}:CANCEL", "ORDER:42:CANCEL:NOW", false, nil},
+		{"ORDER:{id}:{action}", "ORDER:42:SHIP", true, map[string]string{"id": "42", "action": "SHIP"}},
+		{"A.{x}+", "A.1+", true, map[string]string{"x": "1"}},
+		{"A.{x}+", "AB1+", false, nil},
+		{"HELP", "HELP", true, map[string]string{}},
+	}
+	for _, tt := range tests {
+		re, err := compilePayloadPattern(tt.pattern)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.pattern, err)
+		}
+		m := re.FindStringSubmatch(tt.payload)
+		if (m != nil) != tt.match {
+			t.Errorf("%q on %q: expected match %v", tt.pattern, tt.payload, tt.match)
+			continue
+		}
+		if m == nil {
+			continue
+		}
+		for i, name := range re.SubexpNames() {
+			if name != "" && m[i] != tt.params[name] {
+				t.Errorf("%q on %q: expected %s=%q, got %q", tt.pattern, tt.payload, name, tt.params[name], m[i])
+			}
+		}
+	}
+}
+
+func TestCompilePayloadPatternInvalid(t *testing.T) {
+	for _, pattern := range []string{"ORDER:{id", "ORDER:{}", "ORDER:{1d}", "ORDER:{a-b}"} {
+		if _, err := compilePayloadPattern(pattern); err == nil {
+			t.Errorf("%q: expected error", pattern)
+		}
+	}
+}
+
+func TestPayloadRouter(t *testing.T) {
+	r := NewPayloadRouter()
+	var got string
+	r.Exact("HELP", func(ctx context.Context, p PayloadParams, e Event) {
+		got = "help"
+	})
+	r.Pattern("ORDER:{id}:CANCEL", func(ctx context.Context, p PayloadParams, e Event) {
+		got = "cancel " + p["id"]
+	})
+	r.Prefix("ORDER:", func(ctx context.Context, p PayloadParams, e Event) {
+		got = "order " + p["*"]
+	})
+	r.Prefix("O", func(ctx context.Context, p PayloadParams, e Event) {
+		got = "o"
+	})
+	r.NotFound(HandlerFunc(func(ctx context.Context, e Event) {
+		got = "not found"
+	}))
+
+	tests := []struct {
+		event Event
+		want  string
+	}{
+		{&PostbackReceived{Payload: "HELP"}, "help"},
+		{&PostbackReceived{Payload: "ORDER:42:CANCEL"}, "cancel 42"},
+		{&PostbackReceived{Payload: "ORDER:42"}, "order 42"},
+		{&PostbackReceived{Payload: "OTHER"}, "o"},
+		{&PostbackReceived{Payload: "UNKNOWN"}, "not found"},
+		{quickReply("HELP"), "help"},
+		{&MessageReceived{Text: "HELP"}, "not found"},
+	}
+	for _, tt := range tests {
+		got = ""
+		r.HandleEvent(context.Background(), tt.event)
+		if got != tt.want {
+			t.Errorf("%#v: expected %q, got %q", tt.event, tt.want, got)
+		}
+	}
+}
+
+func quickReply(payload string) *MessageReceived {
+	m := &MessageReceived{Text: payload}
+	m.QuickReply = &struct {
+		Payload string `json:"payload"`
+	}{payload}
+	return m
+}
@@ -0,0 +1,241 @@
+package fbmessenger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrUsageExceeded indicates that the Graph API usage limit is reached and
+// access won't be regained before the deadline of the call's context.
+var ErrUsageExceeded = errors.New("usage limit exceeded")
+
+const (
+	// usageThrottleThreshold is the usage percentage from which on
+	// the rate of a limited Sender is reduced.
+	usageThrottleThreshold = 80
+	// minRateScale is the lowest fraction of the configured rate
+	// a limited Sender is slowed down to.
+	minRateScale = 0.1
+)
+
+// Usage is a snapshot of the usage the Graph API reported in the
+// X-App-Usage and X-Business-Use-Case-Usage response headers.
+type Usage struct {
+	// App is the application level usage.
+	App *AppUsage
+	// BusinessUseCase contains the business use case usage by business object ID.
+	BusinessUseCase map[string][]*BusinessUseCaseUsage
+	// UpdatedAt is the time the usage was reported.
+	UpdatedAt time.Time
+}
+
+// AppUsage contains the application level usage as percentages.
+type AppUsage struct {
+	CallCount    int `json:"call_count"`
+	TotalTime    int `json:"total_time"`
+	TotalCPUTime int `json:"total_cputime"`
+}
+
+// BusinessUseCaseUsage contains the usage of a business use case as percentages.
+type BusinessUseCaseUsage struct {
+	Type         string `json:"type"`
+	CallCount    int    `json:"call_count"`
+	TotalTime    int    `json:"total_time"`
+	TotalCPUTime int    `json:"total_cputime"`
+	// EstimatedTimeToRegainAccess is the time in minutes until calls
+	// are no longer throttled.
+	EstimatedTimeToRegainAccess int `json:"estimated_time_to_regain_access"`
+}
+
+// Max returns the highest reported usage percentage.
+func (u *Usage) Max() int {
+	max := 0
+	if u.App != nil {
+		max = maxInt(max, u.App.CallCount, u.App.TotalTime, u.App.TotalCPUTime)
+	}
+	for _, usages := range u.BusinessUseCase {
+		for _, bu := range usages {
+			max = maxInt(max, bu.CallCount, bu.TotalTime, bu.TotalCPUTime)
+		}
+	}
+	return max
+}
+
+// RegainAccessAt returns the time at which throttled calls are estimated
+// to be accepted again. It's the zero time if no estimation was reported.
+func (u *Usage) RegainAccessAt() time.Time {
+	minutes := 0
+	for _, usages := range u.BusinessUseCase {
+		for _, bu := range usages {
+			minutes = maxInt(minutes, bu.EstimatedTimeToRegainAccess)
+		}
+	}
+	if minutes == 0 {
+		return time.Time{}
+	}
+	return u.UpdatedAt.Add(time.Duration(minutes) * time.Minute)
+}
+
+func maxInt(v int, vs ...int) int {
+	for _, x := range vs {
+		if x > v {
+			v = x
+		}
+	}
+	return v
+}
+
+// parseUsage parses the usage headers of a response.
+// It returns false if the response doesn't contain any.
+func parseUsage(h http.Header) (Usage, bool) {
+	u := Usage{UpdatedAt: time.Now()}
+	found := false
+	if v := h.Get("X-App-Usage"); v != "" {
+		var app AppUsage
+		if err := json.Unmarshal([]byte(v), &app); err == nil {
+			u.App = &app
+			found = true
+		}
+	}
+	if v := h.Get("X-Business-Use-Case-Usage"); v != "" {
+		var buc map[string][]*BusinessUseCaseUsage
+		if err := json.Unmarshal([]byte(v), &buc); err == nil {
+			u.BusinessUseCase = buc
+			found = true
+		}
+	}
+	return u, found
+}
+
+// RateLimit returns a SenderOption that limits the calls of the Sender
+// to r calls per second with bursts of up to b calls.
+// A Sender uses a single page access token, so the limit applies per
+// page token; share the Sender to share the limit.
+// The rate is reduced once the usage reported by the Graph API
+// approaches its limit, and calls block until access is estimated
+// to be regained once the limit is reached.
+func RateLimit(r float64, b int) SenderOption {
+	return func(s *Sender) error {
+		if r <= 0 || b < 1 {
+			return errors.New("rate limit requires a positive rate and burst")
+		}
+		s.limiter = &tokenBucket{
+			rate:   r,
+			burst:  float64(b),
+			tokens: float64(b),
+			last:   time.Now(),
+		}
+		return nil
+	}
+}
+
+// Usage returns the last usage reported by the Graph API.
+func (s *Sender) Usage() Usage {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+	return s.usage
+}
+
+func (s *Sender) updateUsage(h http.Header) {
+	u, ok := parseUsage(h)
+	if !ok {
+		return
+	}
+	s.usageMu.Lock()
+	s.usage = u
+	s.usageMu.Unlock()
+}
+
+// throttle blocks until the next call is allowed by the rate limiter.
+func (s *Sender) throttle(ctx context.Context) error {
+	if s.limiter == nil {
+		return nil
+	}
+	usage := s.Usage()
+	used := usage.Max()
+	if used >= 100 {
+		if until := usage.RegainAccessAt(); !until.IsZero() {
+			if err := sleepUntil(ctx, until); err != nil {
+				return err
+			}
+			used = 0
+		}
+	}
+	scale := 1.0
+	if used > usageThrottleThreshold {
+		scale = float64(100-used) / float64(100-usageThrottleThreshold)
+		if scale < minRateScale {
+			scale = minRateScale
+		}
+	}
+	return s.limiter.wait(ctx, scale)
+}
+
+// sleepUntil blocks until t. ErrUsageExceeded is returned if
+// the deadline of ctx is before t.
+func sleepUntil(ctx context.Context, t time.Time) error {
+	d := time.Until(t)
+	if d <= 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(t) {
+		return ErrUsageExceeded
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// tokenBucket is a token bucket rate limiter.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// wait takes a token, refilled at the rate scaled by scale,
+// and blocks until it's available.
+func (b *tokenBucket) wait(ctx context.Context, scale float64) error {
+	b.mu.Lock()
+	now := time.Now()
+	rate := b.rate * scale
+	b.tokens += now.Sub(b.last).Seconds() * rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	// reserve the token, the bucket may go into debt
+	b.tokens--
+	var d time.Duration
+	if b.tokens < 0 {
+		d = time.Duration(-b.tokens / rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if d == 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		// give back the reserved token
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
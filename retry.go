@@ -0,0 +1,100 @@
+package fbmessenger
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy defines how failed calls of a Sender are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one.
+	MaxAttempts int
+	// MinBackoff is the backoff before the first retry. It doubles
+	// with every further retry. Defaults to 500ms.
+	MinBackoff time.Duration
+	// MaxBackoff limits the backoff between two attempts. Defaults to 10s.
+	MaxBackoff time.Duration
+	// Retryable reports if a call which failed with the given error
+	// should be retried. Defaults to IsTemporary.
+	Retryable func(error) bool
+}
+
+// Default backoffs of a RetryPolicy.
+const (
+	defaultMinBackoff = 500 * time.Millisecond
+	defaultMaxBackoff = 10 * time.Second
+)
+
+// DefaultRetryPolicy retries transient failures up to two times.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  defaultMinBackoff,
+	MaxBackoff:  defaultMaxBackoff,
+}
+
+// Retry returns a SenderOption that retries failed calls according
+// to the given policy. Calls are only retried if their body can be
+// sent again, calls which upload a file from an io.Reader are never retried.
+// Retries stop as soon as the context of the call is done or the
+// next attempt would exceed its deadline.
+func Retry(p RetryPolicy) SenderOption {
+	return func(s *Sender) error {
+		if p.MaxAttempts < 1 {
+			return errors.New("retry policy requires at least one attempt")
+		}
+		if p.MinBackoff <= 0 {
+			p.MinBackoff = defaultMinBackoff
+		}
+		if p.MaxBackoff <= 0 {
+			p.MaxBackoff = defaultMaxBackoff
+		}
+		if p.MaxBackoff < p.MinBackoff {
+			p.MaxBackoff = p.MinBackoff
+		}
+		if p.Retryable == nil {
+			p.Retryable = IsTemporary
+		}
+		s.retry = &p
+		return nil
+	}
+}
+
+func (p *RetryPolicy) shouldRetry(attempt int, err error) bool {
+	if p == nil || attempt >= p.MaxAttempts {
+		return false
+	}
+	return p.Retryable(err)
+}
+
+// backoff returns the jittered backoff after the given attempt.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.MinBackoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	// use a random backoff in [d/2, d] to spread retries
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+// wait blocks for the backoff after the given attempt. An error is returned
+// if ctx is done or its deadline would be exceeded by the backoff.
+func (p *RetryPolicy) wait(ctx context.Context, attempt int) error {
+	d := p.backoff(attempt)
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return context.DeadlineExceeded
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,160 @@
+package fbmessenger
+
+import (
+	"context"
+	"reflect"
+)
+
+// A Handler responds to an event.
+type Handler interface {
+	HandleEvent(ctx context.Context, e Event)
+}
+
+// HandlerFunc is an adapter to allow the use of ordinary functions as Handler.
+type HandlerFunc func(ctx context.Context, e Event)
+
+// HandleEvent implements Handler interface.
+func (f HandlerFunc) HandleEvent(ctx context.Context, e Event) {
+	f(ctx, e)
+}
+
+// Router dispatches events to the handler registered for their type.
+// Events without a registered handler are given to the fallback handler.
+// Pass it to WebhookEventHandler to handle events with the context of
+// the webhook request.
+// Handlers must be registered before events are dispatched.
+type Router struct {
+	handlers map[reflect.Type]Handler
+	fallback Handler
+
+	middlewares []Middleware
+	chain       Handler
+}
+
+// NewRouter creates a new Router.
+func NewRouter() *Router {
+	return &Router{
+		handlers: map[reflect.Type]Handler{},
+	}
+}
+
+// Handle registers the handler for events of the same type as e,
+// e.g. (*CommentAdded)(nil). A previously registered handler is replaced.
+func (r *Router) Handle(e Event, h Handler) {
+	r.handlers[reflect.TypeOf(e)] = h
+}
+
+// HandleFunc registers the handler function for events of the same type as e.
+func (r *Router) HandleFunc(e Event, f func(ctx context.Context, e Event)) {
+	r.Handle(e, HandlerFunc(f))
+}
+
+// Fallback registers the handler for events without a registered handler.
+func (r *Router) Fallback(h Handler) {
+	r.fallback = h
+}
+
+// Use wraps the dispatching of every event, including the ones
+// given to the fallback handler, with the given middlewares.
+// Middlewares are applied in the order they were added,
+// the first one is the outermost one.
+func (r *Router) Use(mws ...Middleware) {
+	r.middlewares = append(r.middlewares, mws...)
+	r.chain = Chain(HandlerFunc(r.dispatch), r.middlewares...)
+}
+
+// HandleEvent implements Handler interface.
+func (r *Router) HandleEvent(ctx context.Context, e Event) {
+	if r.chain != nil {
+		r.chain.HandleEvent(ctx, e)
+		return
+	}
+	r.dispatch(ctx, e)
+}
+
+func (r *Router) dispatch(ctx context.Context, e Event) {
+	if h, ok := r.handlers[reflect.TypeOf(e)]; ok {
+		h.HandleEvent(ctx, e)
+		return
+	}
+	if r.fallback != nil {
+		r.fallback.HandleEvent(ctx, e)
+	}
+}
+
+// OnMessage registers the handler for MessageReceived events.
+func (r *Router) OnMessage(f func(ctx context.Context, m *MessageReceived)) {
+	r.HandleFunc((*MessageReceived)(nil), func(ctx context.Context, e Event) {
+		f(ctx, e.(*MessageReceived))
+	})
+}
+
+// OnEcho registers the handler for MessageEchoed events.
+func (r *Router) OnEcho(f func(ctx context.Context, m *MessageEchoed)) {
+	r.HandleFunc((*MessageEchoed)(nil), func(ctx context.Context, e Event) {
+		f(ctx, e.(*MessageEchoed))
+	})
+}
+
+// OnDelivery registers the handler for MessageDelivered events.
+func (r *Router) OnDelivery(f func(ctx context.Context, d *MessageDelivered)) {
+	r.HandleFunc((*MessageDelivered)(nil), func(ctx context.Context, e Event) {
+		f(ctx, e.(*MessageDelivered))
+	})
+}
+
+// OnRead registers the handler for MessageRead events.
+func (r *Router) OnRead(f func(ctx context.Context, m *MessageRead)) {
+	r.HandleFunc((*MessageRead)(nil), func(ctx context.Context, e Event) {
+		f(ctx, e.(*MessageRead))
+	})
+}
+
+// OnReaction registers the handler for MessageReacted events.
+func (r *Router) OnReaction(f func(ctx context.Context, m *MessageReacted)) {
+	r.HandleFunc((*MessageReacted)(nil), func(ctx context.Context, e Event) {
+		f(ctx, e.(*MessageReacted))
+	})
+}
+
+// OnPostback registers the handler for PostbackReceived events.
+func (r *Router) OnPostback(f func(ctx context.Context, p *PostbackReceived)) {
+	r.HandleFunc((*PostbackReceived)(nil), func(ctx context.Context, e Event) {
+		f(ctx, e.(*PostbackReceived))
+	})
+}
+
+// OnReferral registers the handler for ReferralUsed events.
+func (r *Router) OnReferral(f func(ctx context.Context, ref *ReferralUsed)) {
+	r.HandleFunc((*ReferralUsed)(nil), func(ctx context.Context, e Event) {
+		f(ctx, e.(*ReferralUsed))
+	})
+}
+
+// OnOptIn registers the handler for OptInTapped events.
+func (r *Router) OnOptIn(f func(ctx context.Context, o *OptInTapped)) {
+	r.HandleFunc((*OptInTapped)(nil), func(ctx context.Context, e Event) {
+		f(ctx, e.(*OptInTapped))
+	})
+}
+
+// OnAccountLinked registers the handler for AccountLinked events.
+func (r *Router) OnAccountLinked(f func(ctx context.Context, a *AccountLinked)) {
+	r.HandleFunc((*AccountLinked)(nil), func(ctx context.Context, e Event) {
+		f(ctx, e.(*AccountLinked))
+	})
+}
+
+// OnAccountUnlinked registers the handler for AccountUnlinked events.
+func (r *Router) OnAccountUnlinked(f func(ctx context.Context, a *AccountUnlinked)) {
+	r.HandleFunc((*AccountUnlinked)(nil), func(ctx context.Context, e Event) {
+		f(ctx, e.(*AccountUnlinked))
+	})
+}
+
+// OnCommentAdded registers the handler for CommentAdded events.
+func (r *Router) OnCommentAdded(f func(ctx context.Context, c *CommentAdded)) {
+	r.HandleFunc((*CommentAdded)(nil), func(ctx context.Context, e Event) {
+		f(ctx, e.(*CommentAdded))
+	})
+}
@@ -8,6 +8,8 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"path"
+	"sync"
 )
 
 var defaultMessengerEndpoint = &url.URL{
@@ -24,6 +26,12 @@ type Sender struct {
 	accessToken string
 	client      *http.Client
 	endpoint    *url.URL
+	platform    Platform
+	retry       *RetryPolicy
+	limiter     *tokenBucket
+
+	usageMu sync.Mutex
+	usage   Usage
 }
 
 // HTTPClient returns a SenderOption that sets the HTTP client.
@@ -34,6 +42,17 @@ func HTTPClient(client *http.Client) SenderOption {
 	}
 }
 
+// Instagram returns a SenderOption that makes the Sender send messages
+// on the Instagram account linked to the page the access token belongs to.
+// Calls still go to the page, the Graph API routes them to Instagram
+// by the recipient; the Messenger Profile is selected by platform.
+func Instagram() SenderOption {
+	return func(s *Sender) error {
+		s.platform = InstagramPlatform
+		return nil
+	}
+}
+
 // Endpoint returns a SenderOption that sets the endpoint URL.
 func Endpoint(u *url.URL) SenderOption {
 	return func(s *Sender) error {
@@ -60,6 +79,9 @@ func NewSender(accessToken string, opts ...SenderOption) (*Sender, error) {
 	if s.endpoint == nil {
 		s.endpoint = defaultMessengerEndpoint
 	}
+	if s.platform == "" {
+		s.platform = MessengerPlatform
+	}
 
 	// copy the configured endpoint and
 	// add the access token as query parameter
@@ -80,13 +102,30 @@ type MessageResponse struct {
 }
 
 // SendMessage sends a message.
+// A MultimediaAttachment with a Reader is streamed as multipart/form-data.
+// If ctx is canceled or its deadline is exceeded before the call completes,
+// the error returned is ctx.Err().
 func (s *Sender) SendMessage(ctx context.Context, msg *Message) (*MessageResponse, error) {
 	src, err := msg.Source()
 	if err != nil {
 		return nil, err
 	}
 	var resp MessageResponse
-	if err := s.send(src, &resp); err != nil {
+	if a, ok := msg.Attachment.(*MultimediaAttachment); ok && a.Reader != nil {
+		req, err := s.multipartRequest(s.endpoint.String(), src.(map[string]interface{}), &filePart{
+			reader:      a.Reader,
+			filename:    a.Filename,
+			contentType: a.ContentType,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := s.do(ctx, req, &resp); err != nil {
+			return nil, err
+		}
+		return &resp, nil
+	}
+	if err := s.send(ctx, src, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
@@ -105,34 +144,155 @@ const (
 	TypingOff SenderAction = "typing_off"
 )
 
+const (
+	reactAction   SenderAction = "react"
+	unreactAction SenderAction = "unreact"
+)
+
+// SendReaction reacts to the message with the given ID with the given
+// reaction, e.g. "love". An empty reaction removes the page's reaction.
+func (s *Sender) SendReaction(ctx context.Context, to Recipient, messageID string, reaction string) error {
+	recipient, err := to.Source()
+	if err != nil {
+		return err
+	}
+	payload := map[string]interface{}{
+		"message_id": messageID,
+	}
+	action := unreactAction
+	if reaction != "" {
+		payload["reaction"] = reaction
+		action = reactAction
+	}
+	return s.send(ctx, map[string]interface{}{
+		"recipient":     recipient,
+		"sender_action": action,
+		"payload":       payload,
+	}, nil)
+}
+
 // SendAction sends a sender action.
+// If ctx is canceled or its deadline is exceeded before the call completes,
+// the error returned is ctx.Err().
 func (s *Sender) SendAction(ctx context.Context, to Recipient, action SenderAction) error {
 	recipient, err := to.Source()
 	if err != nil {
 		return err
 	}
-	return s.send(map[string]interface{}{
+	return s.send(ctx, map[string]interface{}{
 		"recipient":     recipient,
 		"sender_action": action,
 	}, nil)
 }
 
-func (s *Sender) send(src interface{}, dst interface{}) error {
-	body, err := json.Marshal(src)
-	if err != nil {
+func (s *Sender) send(ctx context.Context, src interface{}, dst interface{}) error {
+	return s.call(ctx, http.MethodPost, s.endpoint.String(), src, dst)
+}
+
+// call sends src encoded as JSON to the given URL.
+// The request has no body if src is nil.
+func (s *Sender) call(ctx context.Context, method, u string, src interface{}, dst interface{}) error {
+	req := &request{
+		method: method,
+		url:    u,
+	}
+	if src != nil {
+		body, err := json.Marshal(src)
+		if err != nil {
+			return err
+		}
+		req.contentType = "application/json"
+		req.body = func() io.Reader {
+			return bytes.NewReader(body)
+		}
+	}
+	return s.do(ctx, req, dst)
+}
+
+// edgeURL returns the URL of the given edge of the node
+// the messages endpoint belongs to, with the given query parameters added.
+func (s *Sender) edgeURL(edge string, params url.Values) string {
+	u := *s.endpoint
+	u.Path = path.Join(path.Dir(u.Path), edge)
+	if len(params) > 0 {
+		qs := u.Query()
+		for k, vs := range params {
+			qs[k] = vs
+		}
+		u.RawQuery = qs.Encode()
+	}
+	return u.String()
+}
+
+// Platform returns the platform the Sender sends messages on.
+func (s *Sender) Platform() Platform {
+	return s.platform
+}
+
+// nodeURL returns the URL of the node with the given ID,
+// with the given query parameters added.
+func (s *Sender) nodeURL(id string, params url.Values) string {
+	return s.edgeURL(path.Join("..", id), params)
+}
+
+// request describes a call to the Graph API.
+type request struct {
+	method      string
+	url         string
+	contentType string
+	// body returns the body for an attempt. It's nil for requests without a body.
+	body func() io.Reader
+	// once is set if the body can only be read once, so the request can't be retried.
+	once bool
+}
+
+// do performs req and decodes the response into dst.
+// Failed attempts are retried according to the retry policy.
+func (s *Sender) do(ctx context.Context, req *request, dst interface{}) error {
+	for attempt := 1; ; attempt++ {
+		err := s.roundTrip(ctx, req, dst)
+		if err == nil || req.once || !s.retry.shouldRetry(attempt, err) {
+			return err
+		}
+		if werr := s.retry.wait(ctx, attempt); werr != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			return err
+		}
+	}
+}
+
+func (s *Sender) roundTrip(ctx context.Context, req *request, dst interface{}) error {
+	if err := s.throttle(ctx); err != nil {
 		return err
 	}
-	call, err := http.NewRequest("POST", s.endpoint.String(), bytes.NewReader(body))
+	var body io.Reader
+	if req.body != nil {
+		body = req.body()
+	}
+	call, err := http.NewRequestWithContext(ctx, req.method, req.url, body)
 	if err != nil {
+		if c, ok := body.(io.Closer); ok {
+			c.Close()
+		}
 		return err
 	}
-	call.Header.Set("Content-Type", "application/json")
+	if req.contentType != "" {
+		call.Header.Set("Content-Type", req.contentType)
+	}
 
 	resp, err := s.client.Do(call)
 	if err != nil {
+		// prefer the context's error, so cancellation can be
+		// distinguished from other transport errors
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
 		return err
 	}
 	defer resp.Body.Close()
+	s.updateUsage(resp.Header)
 
 	if err := s.checkResponse(resp); err != nil {
 		return err
@@ -150,31 +310,20 @@ func (s *Sender) checkResponse(resp *http.Response) error {
 	}
 	slurp, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return &fbError{
-			Message: err.Error(),
-			Code:    resp.StatusCode,
+		return &GraphError{
+			Message:    err.Error(),
+			StatusCode: resp.StatusCode,
 		}
 	}
 	var errResp struct {
-		Error fbError `json:"error"`
+		Error GraphError `json:"error"`
 	}
 	if err := json.Unmarshal(slurp, &errResp); err != nil {
-		return &fbError{
-			Message: err.Error(),
-			Code:    resp.StatusCode,
+		return &GraphError{
+			Message:    err.Error(),
+			StatusCode: resp.StatusCode,
 		}
 	}
+	errResp.Error.StatusCode = resp.StatusCode
 	return &errResp.Error
 }
-
-type fbError struct {
-	Message      string `json:"message"`
-	Type         string `json:"type"`
-	Code         int    `json:"code"`
-	ErrorSubcode int    `json:"error_subcode"`
-	FBTraceID    string `json:"fbtrace_id"`
-}
-
-func (err *fbError) Error() string {
-	return err.Message
-}
@@ -0,0 +1,73 @@
+package fbmessenger
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestInstagramSenderURLs(t *testing.T) {
+	s, err := NewSender("token", Instagram())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Platform() != InstagramPlatform {
+		t.Fatalf("expected platform %q, got %q", InstagramPlatform, s.Platform())
+	}
+
+	tests := []struct {
+		name     string
+		got      string
+		path     string
+		platform string
+	}{
+		{"messages", s.endpoint.String(), "/v2.6/me/messages", ""},
+		{"messenger profile", s.profileURL(nil), "/v2.6/me/messenger_profile", "instagram"},
+		{"attachments", s.edgeURL("message_attachments", nil), "/v2.6/me/message_attachments", ""},
+		{"pass thread control", s.edgeURL("pass_thread_control", nil), "/v2.6/me/pass_thread_control", ""},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.Path != tt.path {
+			t.Errorf("%s: expected path %q, got %q", tt.name, tt.path, u.Path)
+		}
+		if p := u.Query().Get("platform"); p != tt.platform {
+			t.Errorf("%s: expected platform %q, got %q", tt.name, tt.platform, p)
+		}
+		if tok := u.Query().Get("access_token"); tok != "token" {
+			t.Errorf("%s: expected access token, got %q", tt.name, tok)
+		}
+	}
+}
+
+func TestSendMessageReaderConflicts(t *testing.T) {
+	s, err := NewSender("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := FileContent{Reader: strings.NewReader("data"), Filename: "cat.png"}
+	tests := []struct {
+		name string
+		msg  *Message
+		err  error
+	}{
+		{"text", &Message{
+			To:         User("1"),
+			Text:       "hi",
+			Attachment: &MultimediaAttachment{Type: Image, FileContent: file},
+		}, ErrAttachmentReaderWithText},
+		{"attachment id", &Message{
+			To:         User("1"),
+			Attachment: &MultimediaAttachment{Type: Image, AttachmentID: "42", FileContent: file},
+		}, ErrAttachmentReaderWithID},
+	}
+	for _, tt := range tests {
+		if _, err := s.SendMessage(context.Background(), tt.msg); err != tt.err {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.err, err)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package fbmessenger
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// TextMatch contains the result of matching the text of a message.
+type TextMatch struct {
+	// Text is the text of the message.
+	Text string
+	// Keyword is the matched keyword.
+	Keyword string
+	// Captures contains the submatches of a regular expression,
+	// Captures[0] is the text of the whole match.
+	Captures []string
+	// Named contains the named submatches of a regular expression.
+	Named map[string]string
+	// Command is the name of the matched command.
+	Command string
+	// Args are the whitespace separated arguments of the matched command.
+	Args []string
+}
+
+// A TextPattern matches the text of a message.
+type TextPattern interface {
+	MatchText(text string) (*TextMatch, bool)
+}
+
+// Keywords returns a TextPattern which matches texts containing any of the
+// given keywords as whole words. Keywords may consist of several words.
+// Matching ignores case and diacritics, e.g. "creme" matches "Crème".
+func Keywords(keywords ...string) TextPattern {
+	p := &keywordPattern{}
+	for _, kw := range keywords {
+		p.keywords = append(p.keywords, kw)
+		p.words = append(p.words, foldWords(kw))
+	}
+	return p
+}
+
+type keywordPattern struct {
+	keywords []string
+	words    [][]string
+}
+
+// MatchText implements TextPattern interface.
+func (p *keywordPattern) MatchText(text string) (*TextMatch, bool) {
+	words := foldWords(text)
+	for i, kw := range p.words {
+		if len(kw) > 0 && containsWords(words, kw) {
+			return &TextMatch{
+				Text:    text,
+				Keyword: p.keywords[i],
+			}, true
+		}
+	}
+	return nil, false
+}
+
+// containsWords returns if sub is a contiguous sequence of words.
+func containsWords(words, sub []string) bool {
+	for i := 0; i+len(sub) <= len(words); i++ {
+		match := true
+		for j := range sub {
+			if words[i+j] != sub[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// letterFolder folds the letters which have no canonical decomposition.
+var letterFolder = strings.NewReplacer(
+	"æ", "ae", "đ", "d", "ı", "i", "ł", "l", "ø", "o", "œ", "oe", "ß", "ss",
+)
+
+// foldWords returns the words of s in lower case and without diacritics.
+// The combining marks of decomposed Latin, Greek and Cyrillic letters are
+// removed, the ones of other scripts distinguish words and are kept.
+func foldWords(s string) []string {
+	s = norm.NFD.String(strings.ToLower(s))
+	var b strings.Builder
+	strip := false
+	for _, r := range s {
+		if unicode.Is(unicode.Mn, r) {
+			if strip {
+				continue
+			}
+		} else {
+			strip = unicode.In(r, unicode.Latin, unicode.Greek, unicode.Cyrillic)
+		}
+		b.WriteRune(r)
+	}
+	s = letterFolder.Replace(b.String())
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r)
+	})
+}
+
+// Regexp returns a TextPattern which matches texts matching the given
+// regular expression. Its submatches are captured.
+func Regexp(re *regexp.Regexp) TextPattern {
+	return &regexpPattern{re: re}
+}
+
+type regexpPattern struct {
+	re *regexp.Regexp
+}
+
+// MatchText implements TextPattern interface.
+func (p *regexpPattern) MatchText(text string) (*TextMatch, bool) {
+	captures := p.re.FindStringSubmatch(text)
+	if captures == nil {
+		return nil, false
+	}
+	named := map[string]string{}
+	for i, name := range p.re.SubexpNames() {
+		if name != "" {
+			named[name] = captures[i]
+		}
+	}
+	return &TextMatch{
+		Text:     text,
+		Captures: captures,
+		Named:    named,
+	}, true
+}
+
+// Command returns a TextPattern which matches slash-style commands with
+// the given name, e.g. "/order 42 large" for the name "order".
+// The name is matched case insensitive.
+func Command(name string) TextPattern {
+	return &commandPattern{name: strings.TrimPrefix(name, "/")}
+}
+
+type commandPattern struct {
+	name string
+}
+
+// MatchText implements TextPattern interface.
+func (p *commandPattern) MatchText(text string) (*TextMatch, bool) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return nil, false
+	}
+	if !strings.EqualFold(fields[0][1:], p.name) {
+		return nil, false
+	}
+	return &TextMatch{
+		Text:    text,
+		Command: p.name,
+		Args:    fields[1:],
+	}, true
+}
+
+// A TextHandler handles a message whose text matched a TextPattern.
+type TextHandler func(ctx context.Context, m *TextMatch, msg *MessageReceived)
+
+// TextMatcher dispatches MessageReceived events to handlers by their text.
+// Patterns are tried by descending priority, patterns of the same
+// priority in the order they were registered. Events without a matching
+// pattern or text are given to the default handler.
+// Handlers must be registered before events are dispatched.
+type TextMatcher struct {
+	rules []*textRule
+	def   Handler
+}
+
+type textRule struct {
+	priority int
+	pattern  TextPattern
+	handler  TextHandler
+}
+
+// NewTextMatcher creates a new TextMatcher.
+func NewTextMatcher() *TextMatcher {
+	return &TextMatcher{}
+}
+
+// Handle registers the handler for the given pattern with priority 0.
+func (m *TextMatcher) Handle(p TextPattern, h TextHandler) {
+	m.HandlePriority(0, p, h)
+}
+
+// HandlePriority registers the handler for the given pattern with the given priority.
+// Patterns with a negative priority can serve as fallbacks.
+func (m *TextMatcher) HandlePriority(priority int, p TextPattern, h TextHandler) {
+	m.rules = append(m.rules, &textRule{
+		priority: priority,
+		pattern:  p,
+		handler:  h,
+	})
+	sort.SliceStable(m.rules, func(i, j int) bool {
+		return m.rules[i].priority > m.rules[j].priority
+	})
+}
+
+// Default registers the handler for events without a matching pattern.
+func (m *TextMatcher) Default(h Handler) {
+	m.def = h
+}
+
+// HandleEvent implements Handler interface.
+func (m *TextMatcher) HandleEvent(ctx context.Context, e Event) {
+	if msg, ok := e.(*MessageReceived); ok && msg.Text != "" {
+		for _, r := range m.rules {
+			if match, ok := r.pattern.MatchText(msg.Text); ok {
+				r.handler(ctx, match, msg)
+				return
+			}
+		}
+	}
+	if m.def != nil {
+		m.def.HandleEvent(ctx, e)
+	}
+}
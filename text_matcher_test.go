@@ -0,0 +1,68 @@
+package fbmessenger
+
+import (
+	"context"
+	"regexp"
+	"testing"
+)
+
+func TestKeywords(t *testing.T) {
+	p := Keywords("creme brulee", "hi", "tara", "stefan", "tiet", "ไม่")
+	tests := []struct {
+		text  string
+		match bool
+	}{
+		{"I want CRÈME BRÛLÉE!", true},
+		{"I want cre\u0301me bru\u0302le\u0301e", true},
+		{"Hi there", true},
+		{"Țară", true},
+		{"ţară", true},
+		{"Ștefan", true},
+		{"Ştefan", true},
+		{"tiệt", true},
+		{"ไม่", true},
+		{"ไม้", false},
+		{"this", false},
+		{"creme and brulee", false},
+	}
+	for _, tt := range tests {
+		if _, ok := p.MatchText(tt.text); ok != tt.match {
+			t.Errorf("%q: expected match %v", tt.text, tt.match)
+		}
+	}
+}
+
+func TestTextMatcher(t *testing.T) {
+	m := NewTextMatcher()
+	var got string
+	m.Handle(Command("order"), func(ctx context.Context, tm *TextMatch, msg *MessageReceived) {
+		got = "order " + tm.Args[0]
+	})
+	m.HandlePriority(-1, Regexp(regexp.MustCompile(`(?i)^track (?P<id>\d+)$`)), func(ctx context.Context, tm *TextMatch, msg *MessageReceived) {
+		got = "track " + tm.Named["id"]
+	})
+	m.HandlePriority(1, Keywords("help"), func(ctx context.Context, tm *TextMatch, msg *MessageReceived) {
+		got = "help"
+	})
+	m.Default(HandlerFunc(func(ctx context.Context, e Event) {
+		got = "default"
+	}))
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"/ORDER 42 large", "order 42"},
+		{"/order help", "help"},
+		{"Track 7", "track 7"},
+		{"hello", "default"},
+		{"", "default"},
+	}
+	for _, tt := range tests {
+		got = ""
+		m.HandleEvent(context.Background(), &MessageReceived{Text: tt.text})
+		if got != tt.want {
+			t.Errorf("%q: expected %q, got %q", tt.text, tt.want, got)
+		}
+	}
+}
@@ -1,5 +1,24 @@
 package fbmessenger
 
+import (
+	"errors"
+)
+
+var (
+	// ErrMessageTagWithoutType indicates that a message tag was set
+	// without the MessageTagType messaging type.
+	ErrMessageTagWithoutType = errors.New("message tag requires messaging type MESSAGE_TAG")
+	// ErrMessageTagMissing indicates that the MessageTagType messaging type
+	// was set without a message tag.
+	ErrMessageTagMissing = errors.New("messaging type MESSAGE_TAG requires a message tag")
+	// ErrAttachmentReaderWithText indicates that a message with text was
+	// given a MultimediaAttachment with a Reader, which can't be sent together.
+	ErrAttachmentReaderWithText = errors.New("attachment with a reader can't be sent with text")
+	// ErrAttachmentReaderWithID indicates that a MultimediaAttachment has
+	// both an AttachmentID and a Reader.
+	ErrAttachmentReaderWithID = errors.New("attachment can't have both an attachment ID and a reader")
+)
+
 // Object is any type that represents a unit of a message.
 type Object interface {
 	Source() (interface{}, error)
@@ -36,6 +55,42 @@ func (n PhoneNumber) Source() (interface{}, error) {
 
 func (n PhoneNumber) isRecipient() {}
 
+// CommentID represents a comment on a page post to privately reply to.
+type CommentID string
+
+// Source implements Object interface.
+func (c CommentID) Source() (interface{}, error) {
+	return map[string]string{
+		"comment_id": string(c),
+	}, nil
+}
+
+func (c CommentID) isRecipient() {}
+
+// PostID represents a visitor post on a page to privately reply to.
+type PostID string
+
+// Source implements Object interface.
+func (p PostID) Source() (interface{}, error) {
+	return map[string]string{
+		"post_id": string(p),
+	}, nil
+}
+
+func (p PostID) isRecipient() {}
+
+// UserRef represents a user who opted in via the Checkbox plugin.
+type UserRef string
+
+// Source implements Object interface.
+func (r UserRef) Source() (interface{}, error) {
+	return map[string]string{
+		"user_ref": string(r),
+	}, nil
+}
+
+func (r UserRef) isRecipient() {}
+
 // NotificationType defines how the receiver should be notified about a message.
 type NotificationType string
 
@@ -48,6 +103,35 @@ const (
 	NoPush NotificationType = "NO_PUSH"
 )
 
+// MessagingType defines the purpose of a message.
+type MessagingType string
+
+const (
+	// ResponseType is a response to a received message.
+	ResponseType MessagingType = "RESPONSE"
+	// UpdateType is a message sent proactively and not in response to a received message.
+	UpdateType MessagingType = "UPDATE"
+	// MessageTagType is a non-promotional message sent outside
+	// the messaging window with a message tag.
+	MessageTagType MessagingType = "MESSAGE_TAG"
+)
+
+// MessageTag defines the use case of a message sent with MessageTagType.
+type MessageTag string
+
+const (
+	// ConfirmedEventUpdate notifies about an upcoming event or an update
+	// for an event the user has registered for.
+	ConfirmedEventUpdate MessageTag = "CONFIRMED_EVENT_UPDATE"
+	// PostPurchaseUpdate notifies about an update for a recent purchase.
+	PostPurchaseUpdate MessageTag = "POST_PURCHASE_UPDATE"
+	// AccountUpdate notifies about a non-recurring change of the user's
+	// application or account.
+	AccountUpdate MessageTag = "ACCOUNT_UPDATE"
+	// HumanAgent allows a human agent to respond to a user within 7 days.
+	HumanAgent MessageTag = "HUMAN_AGENT"
+)
+
 // Message represents a message to be sent.
 type Message struct {
 	To               Recipient
@@ -56,10 +140,21 @@ type Message struct {
 	QuickReplies     []*QuickReply
 	Metadata         string
 	NotificationType NotificationType
+	MessagingType    MessagingType
+	// Tag must be set if and only if MessagingType is MessageTagType.
+	Tag MessageTag
+	// ReplyTo is the ID of the message the message quotes.
+	ReplyTo string
 }
 
 // Source implements Object interface.
 func (m *Message) Source() (interface{}, error) {
+	if m.Tag != "" && m.MessagingType != MessageTagType {
+		return nil, ErrMessageTagWithoutType
+	}
+	if m.Tag == "" && m.MessagingType == MessageTagType {
+		return nil, ErrMessageTagMissing
+	}
 	toSrc, err := m.To.Source()
 	if err != nil {
 		return nil, err
@@ -67,9 +162,18 @@ func (m *Message) Source() (interface{}, error) {
 	src := map[string]interface{}{
 		"recipient": toSrc,
 	}
+	if m.MessagingType != "" {
+		src["messaging_type"] = m.MessagingType
+	}
+	if m.Tag != "" {
+		src["tag"] = m.Tag
+	}
 
 	msg := map[string]interface{}{}
 	if m.Text != "" {
+		if a, ok := m.Attachment.(*MultimediaAttachment); ok && a.Reader != nil {
+			return nil, ErrAttachmentReaderWithText
+		}
 		msg["text"] = m.Text
 
 		if len(m.QuickReplies) > 0 {
@@ -93,6 +197,11 @@ func (m *Message) Source() (interface{}, error) {
 	if m.Metadata != "" {
 		msg["metadata"] = m.Metadata
 	}
+	if m.ReplyTo != "" {
+		msg["reply_to"] = map[string]string{
+			"mid": m.ReplyTo,
+		}
+	}
 
 	src["message"] = msg
 	if m.NotificationType != "" {
@@ -149,18 +258,28 @@ const (
 )
 
 // MultimediaAttachment represents a multimedia attachment.
+// The file is referenced by AttachmentID or URL, or read from Reader.
+// A Reader can't be combined with AttachmentID or the text of a message.
 type MultimediaAttachment struct {
 	Type         MultimediaType
 	URL          string
 	AttachmentID string
 	Reusable     bool
+	FileContent
 }
 
 // Source implements Object interface.
 func (a *MultimediaAttachment) Source() (interface{}, error) {
+	if a.AttachmentID != "" && a.Reader != nil {
+		return nil, ErrAttachmentReaderWithID
+	}
 	payload := map[string]interface{}{}
 	if a.AttachmentID != "" {
 		payload["attachment_id"] = a.AttachmentID
+	} else if a.Reader != nil {
+		if a.Reusable {
+			payload["is_reusable"] = true
+		}
 	} else {
 		payload["url"] = a.URL
 		if a.Reusable {
@@ -0,0 +1,156 @@
+package fbmessenger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUploadSourceMissing indicates that an upload has neither a URL nor a Reader.
+var ErrUploadSourceMissing = errors.New("upload requires a URL or a Reader")
+
+// FileContent represents the content of a file read from an io.Reader.
+type FileContent struct {
+	// Reader provides the content of the file. It's streamed to the
+	// Graph API as multipart/form-data and read only once.
+	Reader io.Reader
+	// Filename is the name of the file read from Reader.
+	Filename string
+	// ContentType is the MIME type of the file read from Reader.
+	// It's derived from the extension of Filename if empty.
+	ContentType string
+}
+
+// AttachmentUpload represents a multimedia file to upload.
+// Either URL or Reader must be set.
+type AttachmentUpload struct {
+	Type MultimediaType
+	URL  string
+	FileContent
+}
+
+// UploadAttachment uploads a multimedia file and returns its attachment ID.
+// The attachment is reusable and can be sent with MultimediaAttachment.AttachmentID.
+func (s *Sender) UploadAttachment(ctx context.Context, up *AttachmentUpload) (string, error) {
+	payload := map[string]interface{}{
+		"is_reusable": true,
+	}
+	msg := map[string]interface{}{
+		"attachment": map[string]interface{}{
+			"type":    up.Type,
+			"payload": payload,
+		},
+	}
+
+	var resp MessageResponse
+	switch {
+	case up.Reader != nil:
+		req, err := s.multipartRequest(s.edgeURL("message_attachments", nil), map[string]interface{}{
+			"message": msg,
+		}, &filePart{
+			reader:      up.Reader,
+			filename:    up.Filename,
+			contentType: up.ContentType,
+		})
+		if err != nil {
+			return "", err
+		}
+		if err := s.do(ctx, req, &resp); err != nil {
+			return "", err
+		}
+	case up.URL != "":
+		payload["url"] = up.URL
+		if err := s.call(ctx, http.MethodPost, s.edgeURL("message_attachments", nil), map[string]interface{}{
+			"message": msg,
+		}, &resp); err != nil {
+			return "", err
+		}
+	default:
+		return "", ErrUploadSourceMissing
+	}
+	return resp.AttachmentID, nil
+}
+
+// filePart is a file sent as the filedata part of a multipart request.
+type filePart struct {
+	reader      io.Reader
+	filename    string
+	contentType string
+}
+
+// multipartRequest returns a request which streams the given fields,
+// encoded as JSON, followed by the file as multipart/form-data.
+// String fields are sent as is.
+func (s *Sender) multipartRequest(url string, fields map[string]interface{}, file *filePart) (*request, error) {
+	values := map[string]string{}
+	for name, field := range fields {
+		v, err := json.Marshal(field)
+		if err != nil {
+			return nil, err
+		}
+		if len(v) > 0 && v[0] == '"' {
+			var str string
+			if err := json.Unmarshal(v, &str); err != nil {
+				return nil, err
+			}
+			values[name] = str
+			continue
+		}
+		values[name] = string(v)
+	}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	return &request{
+		method:      http.MethodPost,
+		url:         url,
+		contentType: mw.FormDataContentType(),
+		body: func() io.Reader {
+			go func() {
+				pw.CloseWithError(writeMultipart(mw, values, file))
+			}()
+			return pr
+		},
+		once: true,
+	}, nil
+}
+
+func writeMultipart(mw *multipart.Writer, values map[string]string, file *filePart) error {
+	for name, v := range values {
+		if err := mw.WriteField(name, v); err != nil {
+			return err
+		}
+	}
+
+	contentType := file.contentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(file.filename))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Disposition", `form-data; name="filedata"; filename="`+escapeQuotes(file.filename)+`"`)
+	h.Set("Content-Type", contentType)
+	w, err := mw.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, file.reader); err != nil {
+		return err
+	}
+	return mw.Close()
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
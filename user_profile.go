@@ -0,0 +1,232 @@
+package fbmessenger
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidPSID indicates that a page-scoped user ID is empty or malformed.
+var ErrInvalidPSID = errors.New("invalid page-scoped user id")
+
+// UserProfileField is a field of the User Profile.
+type UserProfileField string
+
+// User Profile fields.
+const (
+	UserFirstName  UserProfileField = "first_name"
+	UserLastName   UserProfileField = "last_name"
+	UserProfilePic UserProfileField = "profile_pic"
+	UserLocale     UserProfileField = "locale"
+	UserTimezone   UserProfileField = "timezone"
+	UserGender     UserProfileField = "gender"
+)
+
+// DefaultUserProfileFields are the fields fetched if none are given.
+var DefaultUserProfileFields = []UserProfileField{
+	UserFirstName,
+	UserLastName,
+	UserProfilePic,
+}
+
+// UserProfile contains information about a user.
+// Fields which weren't requested are empty.
+type UserProfile struct {
+	ID         string  `json:"id"`
+	FirstName  string  `json:"first_name"`
+	LastName   string  `json:"last_name"`
+	ProfilePic string  `json:"profile_pic"`
+	Locale     string  `json:"locale"`
+	Timezone   float64 `json:"timezone"`
+	Gender     string  `json:"gender"`
+}
+
+// A ProfileFetcher fetches the profile of a user by its page-scoped ID.
+type ProfileFetcher interface {
+	FetchProfile(ctx context.Context, psid string, fields ...UserProfileField) (*UserProfile, error)
+}
+
+// FetchProfile fetches the given fields of the profile of a user.
+// DefaultUserProfileFields are fetched if none are given.
+func (s *Sender) FetchProfile(ctx context.Context, psid string, fields ...UserProfileField) (*UserProfile, error) {
+	if psid == "" || strings.ContainsAny(psid, "/?#.") {
+		return nil, ErrInvalidPSID
+	}
+	if len(fields) == 0 {
+		fields = DefaultUserProfileFields
+	}
+	var p UserProfile
+	u := s.nodeURL(psid, url.Values{
+		"fields": {joinUserProfileFields(fields)},
+	})
+	if err := s.call(ctx, http.MethodGet, u, nil, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func joinUserProfileFields(fields []UserProfileField) string {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = string(f)
+	}
+	return strings.Join(names, ",")
+}
+
+// profileFetchTimeout limits the duration of a fetch shared by
+// the callers of a CachedProfileFetcher.
+const profileFetchTimeout = 30 * time.Second
+
+// CachedProfileFetcher is a ProfileFetcher which caches the profiles
+// fetched by another ProfileFetcher. Concurrent fetches of the same
+// profile are de-duplicated. Failed fetches aren't cached.
+// A shared fetch isn't canceled with the context of any caller, each
+// caller stops waiting for it once its own context is done.
+type CachedProfileFetcher struct {
+	fetcher ProfileFetcher
+	size    int
+	ttl     time.Duration
+
+	mu       sync.Mutex
+	entries  map[string]*list.Element
+	lru      *list.List
+	inflight map[string]*profileFetch
+}
+
+type profileCacheEntry struct {
+	key       string
+	profile   *UserProfile
+	expiresAt time.Time
+}
+
+// profileFetch is a fetch in progress.
+type profileFetch struct {
+	done    chan struct{}
+	profile *UserProfile
+	err     error
+	// invalidated is set if the profile was invalidated during the
+	// fetch, so the fetched profile isn't cached.
+	invalidated bool
+}
+
+// NewCachedProfileFetcher creates a new CachedProfileFetcher which
+// keeps up to size profiles for the duration of ttl. The least
+// recently used profile is evicted once the cache is full.
+func NewCachedProfileFetcher(f ProfileFetcher, size int, ttl time.Duration) *CachedProfileFetcher {
+	return &CachedProfileFetcher{
+		fetcher:  f,
+		size:     size,
+		ttl:      ttl,
+		entries:  map[string]*list.Element{},
+		lru:      list.New(),
+		inflight: map[string]*profileFetch{},
+	}
+}
+
+// FetchProfile implements ProfileFetcher interface.
+func (c *CachedProfileFetcher) FetchProfile(ctx context.Context, psid string, fields ...UserProfileField) (*UserProfile, error) {
+	if len(fields) == 0 {
+		fields = DefaultUserProfileFields
+	}
+	key := psid + "|" + joinUserProfileFields(fields)
+
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*profileCacheEntry)
+		if time.Now().Before(entry.expiresAt) {
+			c.lru.MoveToFront(el)
+			c.mu.Unlock()
+			return copyProfile(entry.profile), nil
+		}
+		c.removeElement(el)
+	}
+	f, ok := c.inflight[key]
+	if !ok {
+		f = &profileFetch{done: make(chan struct{})}
+		c.inflight[key] = f
+		// the fetch is shared by all callers, so it must not be
+		// canceled by the context of the caller who started it
+		go c.fetch(context.WithoutCancel(ctx), key, psid, fields, f)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-f.done:
+		if f.err != nil {
+			return nil, f.err
+		}
+		return copyProfile(f.profile), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetch fetches a profile for all callers waiting on f
+// and caches it on success.
+func (c *CachedProfileFetcher) fetch(ctx context.Context, key, psid string, fields []UserProfileField, f *profileFetch) {
+	ctx, cancel := context.WithTimeout(ctx, profileFetchTimeout)
+	defer cancel()
+	f.profile, f.err = c.fetcher.FetchProfile(ctx, psid, fields...)
+
+	c.mu.Lock()
+	if c.inflight[key] == f {
+		delete(c.inflight, key)
+	}
+	if f.err == nil && !f.invalidated {
+		c.add(key, f.profile)
+	}
+	c.mu.Unlock()
+	close(f.done)
+}
+
+// Invalidate removes all cached profiles of the given user.
+// Profiles of fetches in progress aren't cached, later calls of
+// FetchProfile fetch the profile again.
+func (c *CachedProfileFetcher) Invalidate(psid string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prefix := psid + "|"
+	for key, el := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.removeElement(el)
+		}
+	}
+	for key, f := range c.inflight {
+		if strings.HasPrefix(key, prefix) {
+			f.invalidated = true
+			delete(c.inflight, key)
+		}
+	}
+}
+
+func (c *CachedProfileFetcher) add(key string, p *UserProfile) {
+	if c.size <= 0 {
+		return
+	}
+	if el, ok := c.entries[key]; ok {
+		c.removeElement(el)
+	}
+	c.entries[key] = c.lru.PushFront(&profileCacheEntry{
+		key:       key,
+		profile:   p,
+		expiresAt: time.Now().Add(c.ttl),
+	})
+	for c.lru.Len() > c.size {
+		c.removeElement(c.lru.Back())
+	}
+}
+
+func (c *CachedProfileFetcher) removeElement(el *list.Element) {
+	c.lru.Remove(el)
+	delete(c.entries, el.Value.(*profileCacheEntry).key)
+}
+
+func copyProfile(p *UserProfile) *UserProfile {
+	cp := *p
+	return &cp
+}
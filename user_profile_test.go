@@ -0,0 +1,52 @@
+package fbmessenger
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// profileFetcherFunc is an adapter to use a function as ProfileFetcher.
+type profileFetcherFunc func(ctx context.Context, psid string, fields ...UserProfileField) (*UserProfile, error)
+
+func (f profileFetcherFunc) FetchProfile(ctx context.Context, psid string, fields ...UserProfileField) (*UserProfile, error) {
+	return f(ctx, psid, fields...)
+}
+
+func TestCachedProfileFetcherInvalidateInFlight(t *testing.T) {
+	var calls int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	c := NewCachedProfileFetcher(profileFetcherFunc(func(ctx context.Context, psid string, fields ...UserProfileField) (*UserProfile, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+			<-release
+			return &UserProfile{ID: psid, FirstName: "stale"}, nil
+		}
+		return &UserProfile{ID: psid, FirstName: "fresh"}, nil
+	}), 10, time.Minute)
+
+	done := make(chan *UserProfile)
+	go func() {
+		p, _ := c.FetchProfile(context.Background(), "1")
+		done <- p
+	}()
+	<-started
+	c.Invalidate("1")
+	close(release)
+	if p := <-done; p.FirstName != "stale" {
+		t.Fatalf("expected the in-flight profile, got %q", p.FirstName)
+	}
+
+	p, err := c.FetchProfile(context.Background(), "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.FirstName != "fresh" {
+		t.Errorf("expected the invalidated profile to be fetched again, got %q", p.FirstName)
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("expected 2 fetches, got %d", n)
+	}
+}
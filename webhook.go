@@ -1,15 +1,26 @@
 package fbmessenger
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"hash"
 	"io/ioutil"
 	"net/http"
+	"strings"
 )
 
 var (
 	// ErrVerifyTokenMismatch indicates that the verify token doesn't match.
 	ErrVerifyTokenMismatch = errors.New("verify token mismatch")
+	// ErrSignatureMissing indicates that a callback was received without a signature.
+	ErrSignatureMissing = errors.New("signature missing")
+	// ErrSignatureMismatch indicates that the signature of a callback doesn't match.
+	ErrSignatureMismatch = errors.New("signature mismatch")
 	// errUnknownCallback indicates that a unknown callback was received.
 	errUnknownCallback = errors.New("unknown callback")
 )
@@ -25,14 +36,33 @@ func VerifyToken(t string) WebhookOption {
 	}
 }
 
+// AppSecret returns a WebhookOption which enables the verification
+// of the X-Hub-Signature and X-Hub-Signature-256 headers of callbacks
+// with the given app secret. Callbacks with a missing or invalid
+// signature are rejected.
+func AppSecret(secret string) WebhookOption {
+	return func(h *webhook) {
+		h.appSecret = []byte(secret)
+	}
+}
+
 // An EventListener handles events given to it by the Webhook.
 type EventListener func(Event)
 
 // WebhookHandler returns an http.Handler which handles Facebook Messenger callbacks.
 // Any callback will be emitted to given EventListener.
 func WebhookHandler(l EventListener, opts ...WebhookOption) http.Handler {
+	return WebhookEventHandler(HandlerFunc(func(ctx context.Context, e Event) {
+		l(e)
+	}), opts...)
+}
+
+// WebhookEventHandler returns an http.Handler which handles Facebook Messenger callbacks.
+// Any callback will be given to h with the context of the webhook request,
+// which is canceled once the request is answered.
+func WebhookEventHandler(h Handler, opts ...WebhookOption) http.Handler {
 	wh := &webhook{
-		listener:     l,
+		handler:      h,
 		verifyTokens: map[string]struct{}{},
 	}
 	for _, opt := range opts {
@@ -52,18 +82,19 @@ func WebhookHandler(l EventListener, opts ...WebhookOption) http.Handler {
 }
 
 type webhook struct {
-	listener     EventListener
+	handler      Handler
 	verifyTokens map[string]struct{}
+	appSecret    []byte
 }
 
-func (wh *webhook) emitEvent(e Event) {
-	wh.listener(e)
+func (wh *webhook) emitEvent(ctx context.Context, e Event) {
+	wh.handler.HandleEvent(ctx, e)
 }
 
 func (wh *webhook) handleVerification(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 	if _, ok := wh.verifyTokens[q.Get("hub.verify_token")]; !ok {
-		wh.emitEvent(&VerificationFailed{
+		wh.emitEvent(r.Context(), &VerificationFailed{
 			Token: q.Get("hub.verify_token"),
 			Err:   ErrVerifyTokenMismatch,
 		})
@@ -71,7 +102,7 @@ func (wh *webhook) handleVerification(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	wh.emitEvent(&VerificationCompleted{
+	wh.emitEvent(r.Context(), &VerificationCompleted{
 		Challenge: q.Get("hub.challenge"),
 	})
 	w.WriteHeader(http.StatusOK)
@@ -84,7 +115,17 @@ func (wh *webhook) handleCallbacks(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
-	// TODO(tecbot): verify signature
+	if wh.appSecret != nil {
+		if err := wh.verifySignature(r.Header, body); err != nil {
+			wh.emitEvent(r.Context(), &SignatureInvalid{
+				Signature:    r.Header.Get("X-Hub-Signature"),
+				Signature256: r.Header.Get("X-Hub-Signature-256"),
+				Err:          err,
+			})
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+	}
 
 	var cbs struct {
 		Object string `json:"object"`
@@ -92,6 +133,8 @@ func (wh *webhook) handleCallbacks(w http.ResponseWriter, r *http.Request) {
 			ID        string      `json:"id"`
 			Timestamp int64       `json:"time"`
 			Callbacks []*callback `json:"messaging"`
+			Standby   []*callback `json:"standby"`
+			Changes   []*change   `json:"changes"`
 		} `json:"entry"`
 	}
 	if err := json.Unmarshal(body, &cbs); err != nil {
@@ -101,12 +144,65 @@ func (wh *webhook) handleCallbacks(w http.ResponseWriter, r *http.Request) {
 
 	for _, page := range cbs.Pages {
 		for _, cb := range page.Callbacks {
-			wh.emitEvent(cb.Event(page.ID))
+			wh.emitEvent(r.Context(), cb.Event(Metadata{
+				Platform: Platform(cbs.Object),
+				PageID:   page.ID,
+			}))
+		}
+		for _, cb := range page.Standby {
+			wh.emitEvent(r.Context(), cb.Event(Metadata{
+				Platform: Platform(cbs.Object),
+				PageID:   page.ID,
+				Standby:  true,
+			}))
+		}
+		for _, c := range page.Changes {
+			wh.emitEvent(r.Context(), c.Event(Metadata{
+				Platform: Platform(cbs.Object),
+				PageID:   page.ID,
+			}))
 		}
 	}
 	w.WriteHeader(http.StatusOK)
 }
 
+// verifySignature checks every signature header present on the request.
+// At least one of them must be set.
+func (wh *webhook) verifySignature(header http.Header, body []byte) error {
+	sigs := []struct {
+		header string
+		prefix string
+		hash   func() hash.Hash
+	}{
+		{"X-Hub-Signature", "sha1=", sha1.New},
+		{"X-Hub-Signature-256", "sha256=", sha256.New},
+	}
+	verified := false
+	for _, sig := range sigs {
+		v := header.Get(sig.header)
+		if v == "" {
+			continue
+		}
+		if !strings.HasPrefix(v, sig.prefix) {
+			return ErrSignatureMismatch
+		}
+		expected, err := hex.DecodeString(v[len(sig.prefix):])
+		if err != nil {
+			return ErrSignatureMismatch
+		}
+		mac := hmac.New(sig.hash, wh.appSecret)
+		mac.Write(body)
+		if !hmac.Equal(mac.Sum(nil), expected) {
+			return ErrSignatureMismatch
+		}
+		verified = true
+	}
+	if !verified {
+		return ErrSignatureMissing
+	}
+	return nil
+}
+
 type callback struct {
 	Sender struct {
 		ID string `json:"id"`
@@ -115,7 +211,7 @@ type callback struct {
 		ID string `json:"id"`
 	} `json:"recipient"`
 	Timestamp      int64             `json:"timestamp"`
-	Message        *MessageReceived  `json:"message"`
+	Message        *callbackMessage  `json:"message"`
 	Delivery       *MessageDelivered `json:"delivery"`
 	Read           *MessageRead      `json:"read"`
 	Postback       *PostbackReceived `json:"postback"`
@@ -123,22 +219,57 @@ type callback struct {
 		Status            string `json:"status"`
 		AuthorizationCode string `json:"authorization_code"`
 	} `json:"account_linking"`
-	OptIn    *OptInTapped           `json:"optin"`
-	Referral *ReferralUsed          `json:"referral"`
-	Extra    map[string]interface{} `json:",inline"`
+	OptIn                *OptInTapped            `json:"optin"`
+	Referral             *ReferralUsed           `json:"referral"`
+	Reaction             *MessageReacted         `json:"reaction"`
+	PassThreadControl    *ThreadControlPassed    `json:"pass_thread_control"`
+	TakeThreadControl    *ThreadControlTaken     `json:"take_thread_control"`
+	RequestThreadControl *ThreadControlRequested `json:"request_thread_control"`
+	AppRoles             map[AppID][]string      `json:"app_roles"`
+
+	// raw is the callback as received.
+	raw json.RawMessage
 }
 
-func (cb *callback) Event(pageID string) Event {
-	md := Metadata{
-		PageID:      pageID,
-		SenderID:    cb.Sender.ID,
-		RecipientID: cb.Recipient.ID,
-		Timestamp:   cb.Timestamp,
+// UnmarshalJSON implements json.Unmarshaler interface.
+func (cb *callback) UnmarshalJSON(data []byte) error {
+	type plain callback
+	if err := json.Unmarshal(data, (*plain)(cb)); err != nil {
+		return err
 	}
+	cb.raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// callbackMessage is a received message or the echo of a sent message.
+type callbackMessage struct {
+	MessageReceived
+	IsEcho      bool   `json:"is_echo"`
+	AppID       AppID  `json:"app_id"`
+	AppMetadata string `json:"metadata"`
+}
+
+// Event returns the event of the callback. The metadata of the
+// event is md completed with the sender, recipient and timestamp.
+func (cb *callback) Event(md Metadata) Event {
+	md.SenderID = cb.Sender.ID
+	md.RecipientID = cb.Recipient.ID
+	md.Timestamp = cb.Timestamp
 	var evt interface{}
-	if cb.Message != nil {
-		cb.Message.Metadata = md
-		evt = cb.Message
+	if cb.Message != nil && cb.Message.IsEcho {
+		evt = &MessageEchoed{
+			Metadata:    md,
+			MessageID:   cb.Message.MessageID,
+			Seq:         cb.Message.Seq,
+			Text:        cb.Message.Text,
+			StickerID:   cb.Message.StickerID,
+			Attachments: cb.Message.Attachments,
+			AppID:       cb.Message.AppID,
+			AppMetadata: cb.Message.AppMetadata,
+		}
+	} else if cb.Message != nil {
+		cb.Message.MessageReceived.Metadata = md
+		evt = &cb.Message.MessageReceived
 	} else if cb.Delivery != nil {
 		cb.Delivery.Metadata = md
 		evt = cb.Delivery
@@ -165,11 +296,98 @@ func (cb *callback) Event(pageID string) Event {
 	} else if cb.Referral != nil {
 		cb.Referral.Metadata = md
 		evt = cb.Referral
+	} else if cb.Reaction != nil {
+		cb.Reaction.Metadata = md
+		evt = cb.Reaction
+	} else if cb.PassThreadControl != nil {
+		cb.PassThreadControl.Metadata = md
+		evt = cb.PassThreadControl
+	} else if cb.TakeThreadControl != nil {
+		cb.TakeThreadControl.Metadata = md
+		evt = cb.TakeThreadControl
+	} else if cb.RequestThreadControl != nil {
+		cb.RequestThreadControl.Metadata = md
+		evt = cb.RequestThreadControl
+	} else if cb.AppRoles != nil {
+		evt = &AppRolesChanged{
+			Metadata: md,
+			Roles:    cb.AppRoles,
+		}
 	} else {
+		var extra map[string]json.RawMessage
+		if err := json.Unmarshal(cb.raw, &extra); err == nil {
+			delete(extra, "sender")
+			delete(extra, "recipient")
+			delete(extra, "timestamp")
+		}
 		evt = &CallbackUnsupported{
 			Metadata: md,
-			Extra:    cb.Extra,
+			Raw:      cb.raw,
+			Extra:    extra,
+		}
+	}
+	return evt
+}
+
+// change is a change of a subscribed page field.
+type change struct {
+	Field string          `json:"field"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Event returns the event of the change. The metadata of the
+// event is md completed with the author and creation time.
+func (c *change) Event(md Metadata) Event {
+	unsupported := &FeedChangeUnsupported{
+		Metadata: md,
+		Field:    c.Field,
+		Value:    c.Value,
+	}
+	if c.Field != "feed" {
+		return unsupported
+	}
+	var v struct {
+		Item        string   `json:"item"`
+		Verb        string   `json:"verb"`
+		From        FeedUser `json:"from"`
+		CreatedTime int64    `json:"created_time"`
+	}
+	if err := json.Unmarshal(c.Value, &v); err != nil {
+		return unsupported
+	}
+	md.SenderID = v.From.ID
+	md.RecipientID = md.PageID
+	md.Timestamp = v.CreatedTime * 1000
+	unsupported.Metadata = md
+
+	var evt interface{}
+	switch v.Item {
+	case "comment":
+		switch v.Verb {
+		case "add":
+			evt = &CommentAdded{Metadata: md}
+		case "edit", "edited":
+			evt = &CommentEdited{Metadata: md}
+		case "remove":
+			evt = &CommentRemoved{Metadata: md}
+		}
+	case "reaction":
+		evt = &PostReacted{Metadata: md}
+	case "status", "post", "photo", "video", "share":
+		switch v.Verb {
+		case "add":
+			evt = &PostAdded{Metadata: md}
+		case "edit", "edited":
+			evt = &PostEdited{Metadata: md}
+		case "remove":
+			evt = &PostRemoved{Metadata: md}
 		}
 	}
+	if evt == nil {
+		return unsupported
+	}
+	if err := json.Unmarshal(c.Value, evt); err != nil {
+		return unsupported
+	}
 	return evt
 }
@@ -0,0 +1,95 @@
+package fbmessenger
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testAppSecret = "app-secret"
+
+func sign(h func() hash.Hash, prefix, secret, body string) string {
+	mac := hmac.New(h, []byte(secret))
+	mac.Write([]byte(body))
+	return prefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestWebhookSignature(t *testing.T) {
+	const body = `{"object":"page","entry":[]}`
+	validSHA1 := sign(sha1.New, "sha1=", testAppSecret, body)
+	validSHA256 := sign(sha256.New, "sha256=", testAppSecret, body)
+
+	tests := []struct {
+		name      string
+		sha1      string
+		sha256    string
+		wantCode  int
+		wantError error
+	}{
+		{"valid sha1", validSHA1, "", http.StatusOK, nil},
+		{"valid sha256", "", validSHA256, http.StatusOK, nil},
+		{"valid sha1 and sha256", validSHA1, validSHA256, http.StatusOK, nil},
+		{"valid sha256 with wrong sha1", sign(sha1.New, "sha1=", "wrong", body), validSHA256, http.StatusForbidden, ErrSignatureMismatch},
+		{"wrong secret", "", sign(sha256.New, "sha256=", "wrong", body), http.StatusForbidden, ErrSignatureMismatch},
+		{"missing header", "", "", http.StatusForbidden, ErrSignatureMissing},
+		{"malformed prefix", "", "sha1=" + validSHA256[len("sha256="):], http.StatusForbidden, ErrSignatureMismatch},
+		{"non-hex value", "", "sha256=zzzz", http.StatusForbidden, ErrSignatureMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events []Event
+			h := WebhookHandler(func(e Event) {
+				events = append(events, e)
+			}, AppSecret(testAppSecret))
+
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+			if tt.sha1 != "" {
+				r.Header.Set("X-Hub-Signature", tt.sha1)
+			}
+			if tt.sha256 != "" {
+				r.Header.Set("X-Hub-Signature-256", tt.sha256)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected status %d, got %d", tt.wantCode, w.Code)
+			}
+			if tt.wantError == nil {
+				if len(events) != 0 {
+					t.Fatalf("expected no events, got %v", events)
+				}
+				return
+			}
+			if len(events) != 1 {
+				t.Fatalf("expected one event, got %v", events)
+			}
+			evt, ok := events[0].(*SignatureInvalid)
+			if !ok {
+				t.Fatalf("expected *SignatureInvalid, got %T", events[0])
+			}
+			if evt.Err != tt.wantError {
+				t.Errorf("expected error %v, got %v", tt.wantError, evt.Err)
+			}
+			if evt.Signature != tt.sha1 || evt.Signature256 != tt.sha256 {
+				t.Errorf("expected signatures %q and %q, got %q and %q", tt.sha1, tt.sha256, evt.Signature, evt.Signature256)
+			}
+		})
+	}
+}
+
+func TestWebhookWithoutAppSecret(t *testing.T) {
+	h := WebhookHandler(func(e Event) {})
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"object":"page","entry":[]}`))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}